@@ -0,0 +1,236 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/blainemoser/MySqlMigrate/migrate"
+	utils "github.com/blainemoser/goutils"
+	"github.com/blainemoser/todobot/event"
+	"github.com/blainemoser/todobot/user"
+)
+
+const exportUsers = `select * from users order by id`
+
+// subcommand runs the one of subcommands named by the first argument, for
+// commands such as "todobot users list"
+func subcommand(command string, args []string, subcommands map[string]func(args []string) error) error {
+	names := make([]string, 0)
+	for k := range subcommands {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	if len(args) < 1 {
+		return fmt.Errorf("expected todobot %s %s", command, strings.Join(names, "|"))
+	}
+	run, ok := subcommands[args[0]]
+	if !ok {
+		return fmt.Errorf("unknown command '%s %s', expected one of %s", command, args[0], strings.Join(names, ", "))
+	}
+	return run(args[1:])
+}
+
+// configured loads the configs for a command that takes no other arguments
+// than the config flags; like all the admin commands, it only uses the
+// database
+func configured(command string, args []string) error {
+	flags := configFlags(command)
+	rest, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(rest) > 0 {
+		return fmt.Errorf("unexpected arguments to todobot %s: %s", command, strings.Join(rest, " "))
+	}
+	return configure(flags, databaseGroup)
+}
+
+// migrations runs the migrations in the migrations directory: todobot migrate up
+// or todobot migrate down
+func migrations(args []string) error {
+	return subcommand("migrate", args, map[string]func(args []string) error{
+		"up": func(args []string) error {
+			dir, err := migrationsDirectory("migrate up", args)
+			if err != nil {
+				return err
+			}
+			return migrate.Make(db, dir).MigrateUp()
+		},
+		"down": func(args []string) error {
+			dir, err := migrationsDirectory("migrate down", args)
+			if err != nil {
+				return err
+			}
+			return migrate.Make(db, dir).MigrateDown()
+		},
+	})
+}
+
+// migrationsDirectory loads the configs and checks the migrations directory
+// they name is there
+func migrationsDirectory(command string, args []string) (string, error) {
+	if err := configured(command, args); err != nil {
+		return "", err
+	}
+	dir := env["migrations"]
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return "", fmt.Errorf("no migrations directory found at '%s', set it with TODOBOT_MIGRATIONS or --migrations", dir)
+	}
+	return dir, nil
+}
+
+func users(args []string) error {
+	return subcommand("users", args, map[string]func(args []string) error{
+		"list": usersList,
+	})
+}
+
+// usersList prints the users the bot knows of: todobot users list
+func usersList(args []string) error {
+	if err := configured("users list", args); err != nil {
+		return err
+	}
+	list, err := user.UsersList(db)
+	if err != nil {
+		return err
+	}
+	ids := make([]int64, 0)
+	for id := range list {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i] < ids[j]
+	})
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tUSER\tTIMEZONE\tOFFSET")
+	for _, id := range ids {
+		u := list[id]
+		fmt.Fprintf(w, "%d\t%s\t%s\t%d\n", id, u.Hash(), u.TZ(), u.TZOffset())
+	}
+	return w.Flush()
+}
+
+func events(args []string) error {
+	return subcommand("events", args, map[string]func(args []string) error{
+		"list":  eventsList,
+		"purge": eventsPurge,
+	})
+}
+
+// eventsList prints the stored events, every user's or just one's: todobot
+// events list --user U2147483697
+func eventsList(args []string) error {
+	flags := configFlags("events list")
+	uhash := flags.String("user", "", "the Slack ID of the user whose events to list")
+	_, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+	if err = configure(flags, databaseGroup); err != nil {
+		return err
+	}
+	records, err := event.Stored(db, *uhash)
+	if err != nil {
+		return err
+	}
+	return printEvents(os.Stdout, records)
+}
+
+// eventsPurge deletes old events that aren't active: todobot events purge
+// --inactive --days 30
+func eventsPurge(args []string) error {
+	flags := configFlags("events purge")
+	inactive := flags.Bool("inactive", false, "purge the events that are completed or were never scheduled")
+	days := flags.Int("days", 30, "only purge the events left alone for at least this many days")
+	_, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+	if !*inactive {
+		return fmt.Errorf("expected --inactive; only inactive events can be purged")
+	}
+	if *days < 1 {
+		return fmt.Errorf("expected --days to be at least 1, got %d", *days)
+	}
+	if err = configure(flags, databaseGroup); err != nil {
+		return err
+	}
+	purged, err := event.PurgeInactive(db, *days)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("purged %d inactive events\n", purged)
+	return nil
+}
+
+func printEvents(w io.Writer, records []map[string]interface{}) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tUSER\tSTATUS\tNEXT\tTEXT")
+	for _, rec := range records {
+		next := "-"
+		if nextAt := utils.Int64Interface(rec["next_at"]); nextAt > 0 {
+			next = time.Unix(nextAt, 0).UTC().Format("2006-01-02 15:04 MST")
+		}
+		fmt.Fprintf(
+			tw, "%d\t%s\t%s\t%s\t%s\n",
+			utils.Int64Interface(rec["id"]),
+			utils.StringInterface(rec["uhash"]),
+			eventStatus(rec),
+			next,
+			strings.Join(strings.Fields(utils.StringInterface(rec["etext"])), " "),
+		)
+	}
+	return tw.Flush()
+}
+
+func eventStatus(rec map[string]interface{}) string {
+	switch {
+	case len(utils.StringInterface(rec["completed_at"])) > 0:
+		return "completed"
+	case utils.Int64Interface(rec["schedule"]) > 0 || utils.Int64Interface(rec["once"]) > 0:
+		return "active"
+	default:
+		return "inactive"
+	}
+}
+
+// export writes every user and event as JSON, for backups or moving the bot:
+// todobot export > todobot.json
+func export(args []string) error {
+	if err := configured("export", args); err != nil {
+		return err
+	}
+	userRecords, err := db.QueryRaw(exportUsers, nil)
+	if err != nil {
+		return err
+	}
+	eventRecords, err := event.Stored(db, "")
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(map[string]interface{}{
+		"users":  exportable(userRecords),
+		"events": exportable(eventRecords),
+	})
+}
+
+// exportable converts the raw bytes the driver gives for some columns into
+// strings, which would otherwise be encoded as base64
+func exportable(records []map[string]interface{}) []map[string]interface{} {
+	for _, rec := range records {
+		for k, v := range rec {
+			if b, ok := v.([]byte); ok {
+				rec[k] = string(b)
+			}
+		}
+	}
+	return records
+}
@@ -0,0 +1,22 @@
+package main
+
+import "testing"
+
+func TestEventStatus(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		rec     map[string]interface{}
+		expects string
+	}{
+		{"recurring", map[string]interface{}{"schedule": int64(3600), "once": int64(0)}, "active"},
+		{"one-off", map[string]interface{}{"schedule": int64(0), "once": int64(1)}, "active"},
+		{"removed", map[string]interface{}{"schedule": int64(0), "once": int64(0)}, "inactive"},
+		{"never scheduled", map[string]interface{}{"schedule": nil, "once": nil}, "inactive"},
+		{"fired one-off", map[string]interface{}{"schedule": int64(0), "once": int64(1), "completed_at": "2022-01-30 01:25:00"}, "completed"},
+		{"retired", map[string]interface{}{"schedule": int64(0), "once": int64(0), "completed_at": "2022-01-30 01:25:00"}, "completed"},
+	} {
+		if got := eventStatus(tc.rec); got != tc.expects {
+			t.Errorf("expected a %s event to be %s, got %s", tc.name, tc.expects, got)
+		}
+	}
+}
@@ -12,9 +12,11 @@ import (
 const errorResponse = `{"error": true, "message": "%s"}`
 
 type Api struct {
-	Port       int
-	SlackURL   string
-	slackToken string
+	Port          int
+	SlackURL      string
+	slackToken    string
+	signingSecret string
+	events        *workerPool
 	*database.Database
 	*logging.Log
 }
@@ -67,15 +69,20 @@ func (r *Response) writeError(p interface{}) {
 	r.w.Write(r.message)
 }
 
-func Boot(port int, slackURL, slackToken string, db *database.Database, logger *logging.Log) *Api {
+func Boot(port int, slackURL, slackToken, signingSecret string, db *database.Database, logger *logging.Log) *Api {
 	api := &Api{
-		Port:       port,
-		Log:        logger,
-		Database:   db,
-		SlackURL:   slackURL,
-		slackToken: slackToken,
+		Port:          port,
+		Log:           logger,
+		Database:      db,
+		SlackURL:      slackURL,
+		slackToken:    slackToken,
+		signingSecret: signingSecret,
 	}
+	api.events = newWorkerPool(eventWorkers, eventBacklog, func(err error) {
+		api.ErrLog(err, false)
+	})
 	api.controller()
+	go api.expireProcessedEvents()
 	return api
 }
 
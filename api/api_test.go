@@ -5,9 +5,12 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strings"
 	"testing"
+	"time"
 
+	jsonextract "github.com/blainemoser/JsonExtract"
 	logging "github.com/blainemoser/Logging"
 	"github.com/blainemoser/todobot/event"
 	"github.com/blainemoser/todobot/tests"
@@ -22,6 +25,8 @@ const testSlackChallenge = `{
 
 const testChannelName = "CH0000001"
 
+const testSigningSecret = "8f742231b10e8888abcd99yyyzzz85a5"
+
 var (
 	port      int = 9000
 	a         *Api
@@ -49,7 +54,7 @@ func getAPI() (err error) {
 	if err != nil {
 		return err
 	}
-	a = Boot(port, suite.TestEnv["slackURL"], suite.TestEnv["slackToken"], suite.TestDatabase, l)
+	a = Boot(port, suite.TestEnv["slackURL"], suite.TestEnv["slackToken"], testSigningSecret, suite.TestDatabase, l)
 	go func() {
 		err = a.Run()
 	}()
@@ -74,42 +79,83 @@ func TestPing(t *testing.T) {
 	}
 }
 
-func modifiedTestPayload() *strings.Reader {
+func TestFailedDeliveries(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/deliveries/failed", nil)
+	w := httptest.NewRecorder()
+	a.verified(a.failedDeliveries)(w, req)
+	if w.Result().StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected an unsigned request for failed deliveries to be rejected, got status %d", w.Result().StatusCode)
+	}
+	req = signedRequest("/deliveries/failed", "", testSigningSecret, time.Now())
+	req.Method = http.MethodGet
+	w = httptest.NewRecorder()
+	a.verified(a.failedDeliveries)(w, req)
+	res := w.Result()
+	data, err := testsuite.GetBody(res)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("expected status %d, got %d", http.StatusOK, res.StatusCode)
+	}
+	err = testsuite.EvaluateResult(data, map[string]interface{}{
+		"error": false,
+	})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func modifiedTestPayload() string {
 	payload := strings.Replace(tests.TestEventPayload, "C02NLG80TEH", testChannelName, 1)
 	payload = strings.Replace(payload, "[message]", "remind me to fetch the kids", 1)
-	payload = strings.Replace(payload, "[timestamp]", fmt.Sprintf("%d", timestamp), 1)
-	return strings.NewReader(payload)
+	return strings.Replace(payload, "[timestamp]", fmt.Sprintf("%d", timestamp), 1)
+}
+
+// signedRequest signs the request as Slack would, sent at the given time
+func signedRequest(target, body, secret string, sent time.Time) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+	ts := fmt.Sprintf("%d", sent.Unix())
+	signer := &Api{signingSecret: secret}
+	req.Header.Set("X-Slack-Request-Timestamp", ts)
+	req.Header.Set("X-Slack-Signature", signer.sign(ts, []byte(body)))
+	return req
 }
 
 func TestSlackEvent(t *testing.T) {
-	req := httptest.NewRequest(http.MethodPost, "/slack-event", modifiedTestPayload())
+	req := signedRequest("/slack-event", modifiedTestPayload(), testSigningSecret, time.Now())
 	w := httptest.NewRecorder()
-	a.slackEvent(w, req)
+	a.verified(a.slackEvent)(w, req)
 	res := w.Result()
-	_, err := testsuite.GetBody(res)
+	data, err := testsuite.GetBody(res)
 	if err != nil {
 		t.Fatal(err)
 	}
+	err = testsuite.EvaluateResult(data, map[string]interface{}{
+		"error":   false,
+		"message": "accepted",
+	})
+	if err != nil {
+		t.Error(err)
+	}
+	a.events.wait()
 	if event.Queue == nil {
 		t.Fatalf("event queue is empty")
 	}
-	e := event.Queue.Back()
-	if e == nil || e.Value == nil {
+	events := event.Queue.Events()
+	if len(events) < 1 {
 		t.Fatalf("api queue has no events")
 	}
-	event, ok := e.Value.(*event.Event)
-	if !ok {
-		t.Fatalf("event is not of the event type; type assertion failed")
-	}
+	event := events[len(events)-1]
 	if event.Channel != testChannelName {
 		t.Fatalf("expected event channel to be '%s', got '%s'", testChannelName, event.Channel)
 	}
 }
 
 func TestSlackChallenge(t *testing.T) {
-	req := httptest.NewRequest(http.MethodPost, "/slack-event", strings.NewReader(testSlackChallenge))
+	req := signedRequest("/slack-event", testSlackChallenge, testSigningSecret, time.Now())
 	w := httptest.NewRecorder()
-	a.slackEvent(w, req)
+	a.verified(a.slackEvent)(w, req)
 	res := w.Result()
 	data, err := testsuite.GetBody(res)
 	if err != nil {
@@ -123,6 +169,222 @@ func TestSlackChallenge(t *testing.T) {
 	}
 }
 
+func TestSlackEventReplay(t *testing.T) {
+	payload := strings.Replace(modifiedTestPayload(), "Ev02VBF8EFPD", "Ev0REPLAYED1", 1)
+	received := a.events.metrics()["received"]
+	for retry, expects := range []string{"accepted", "already processed", "already processed", "already processed"} {
+		req := signedRequest("/slack-event", payload, testSigningSecret, time.Now())
+		if retry > 0 {
+			req.Header.Set("X-Slack-Retry-Num", fmt.Sprintf("%d", retry))
+			req.Header.Set("X-Slack-Retry-Reason", "http_timeout")
+		}
+		w := httptest.NewRecorder()
+		a.verified(a.slackEvent)(w, req)
+		data, err := testsuite.GetBody(w.Result())
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = testsuite.EvaluateResult(data, map[string]interface{}{
+			"error":   false,
+			"message": expects,
+		})
+		if err != nil {
+			t.Errorf("delivery %d: %s", retry+1, err.Error())
+		}
+	}
+	a.events.wait()
+	if got := a.events.metrics()["received"] - received; got != 1 {
+		t.Errorf("expected the event to be processed once, got %d", got)
+	}
+}
+
+func TestSlackCommand(t *testing.T) {
+	// the events of the tests before would have the reminder below asked about
+	// rather than added
+	event.ClearQueue()
+	replies, responder := commandResponder()
+	defer responder.Close()
+	for _, tc := range []struct {
+		text    string
+		expects string
+	}{
+		{"help", commandUsage},
+		{"add", commandUsage},
+		{"add fetch the kids every day at 3 pm", "I'll remind you every day at 3:00 pm"},
+		{"list", "Your todo list:"},
+	} {
+		if reply := sendCommand(t, tc.text, responder.URL, replies); !strings.HasPrefix(reply, tc.expects) {
+			t.Errorf("expected the reply to '/todo %s' to start with '%s', got '%s'", tc.text, tc.expects, reply)
+		}
+	}
+}
+
+func TestSlackCommandConfirm(t *testing.T) {
+	event.ClearQueue()
+	replies, responder := commandResponder()
+	defer responder.Close()
+	for _, tc := range []struct {
+		text    string
+		expects string
+	}{
+		{"update", "Sorry"},
+		{"add fetch the kids every day at 3 pm", "I'll remind you every day at 3:00 pm"},
+		{"add fetch the kids every day at 4 pm", "Did you mean to update 'fetch the kids'"},
+		{"update", "I'll remind you every day at 4:00 pm"},
+		{"add fetch the kids every day at 5 pm", "Did you mean to update 'fetch the kids'"},
+		{"new", "I'll remind you every day at 5:00 pm"},
+	} {
+		if reply := sendCommand(t, tc.text, responder.URL, replies); !strings.HasPrefix(reply, tc.expects) {
+			t.Errorf("expected the reply to '/todo %s' to start with '%s', got '%s'", tc.text, tc.expects, reply)
+		}
+	}
+	if events := event.Queue.Events(); len(events) != 2 {
+		t.Errorf("expected the update to replace the reminder and the new one to be added, got %d reminders", len(events))
+	}
+}
+
+// commandResponder stands in for a slash command's response_url, passing on
+// the text of each reply
+func commandResponder() (chan string, *httptest.Server) {
+	replies := make(chan string, 1)
+	responder := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		text, _ := jsonextract.JSONExtract{RawJSON: string(body)}.Extract("text")
+		replies <- fmt.Sprintf("%v", text)
+	}))
+	return replies, responder
+}
+
+// sendCommand sends "/todo text" and returns the reply
+func sendCommand(t *testing.T, text, responseURL string, replies chan string) string {
+	form := url.Values{
+		"command":      {"/todo"},
+		"text":         {text},
+		"user_id":      {"U02DGLZ7ABA"},
+		"channel_id":   {testChannelName},
+		"response_url": {responseURL},
+	}
+	req := signedRequest("/slack-command", form.Encode(), testSigningSecret, time.Now())
+	w := httptest.NewRecorder()
+	a.verified(a.slackCommand)(w, req)
+	if w.Result().StatusCode != http.StatusOK {
+		t.Fatalf("expected '/todo %s' to be acknowledged, got status %d", text, w.Result().StatusCode)
+	}
+	a.events.wait()
+	return <-replies
+}
+
+func TestSlackInteractive(t *testing.T) {
+	replies := make(chan string, 1)
+	responder := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		text, _ := jsonextract.JSONExtract{RawJSON: string(body)}.Extract("text")
+		replies <- fmt.Sprintf("%v", text)
+	}))
+	defer responder.Close()
+	payload := fmt.Sprintf(`{
+		"type": "block_actions",
+		"user": {"id": "U02DGLZ7ABA"},
+		"response_url": "%s",
+		"actions": [{"action_id": "done", "block_id": "reminder", "value": "999999"}]
+	}`, responder.URL)
+	form := url.Values{"payload": {payload}}
+	req := signedRequest("/slack-interactive", form.Encode(), testSigningSecret, time.Now())
+	w := httptest.NewRecorder()
+	a.verified(a.slackInteractive)(w, req)
+	if w.Result().StatusCode != http.StatusOK {
+		t.Fatalf("expected the interaction to be acknowledged, got status %d", w.Result().StatusCode)
+	}
+	a.events.wait()
+	if reply := <-replies; !strings.HasPrefix(reply, "Sorry, I couldn't find that reminder") {
+		t.Errorf("expected a reply saying the reminder wasn't found, got '%s'", reply)
+	}
+}
+
+func TestWorkerPool(t *testing.T) {
+	started := make(chan bool, 4)
+	release := make(chan bool)
+	p := newWorkerPool(2, 1, func(err error) {})
+	job := func(outcome string) func() error {
+		return func() error {
+			started <- true
+			<-release
+			switch outcome {
+			case "fail":
+				return fmt.Errorf("failed")
+			case "panic":
+				panic("panicked")
+			}
+			return nil
+		}
+	}
+	// two jobs are taken by the workers and one waits, so the fourth is turned away
+	accepted := 0
+	for i, outcome := range []string{"ok", "fail", "panic", "ok"} {
+		if p.submit(job(outcome)) {
+			accepted++
+		}
+		if i < 2 {
+			<-started
+		}
+	}
+	close(release)
+	p.wait()
+	for name, expects := range map[string]int64{
+		"received":  3,
+		"processed": 1,
+		"failed":    2,
+		"rejected":  1,
+		"backlog":   0,
+	} {
+		if got := p.metrics()[name]; got != expects {
+			t.Errorf("expected %s to be %d, got %d", name, expects, got)
+		}
+	}
+	if accepted != 3 {
+		t.Errorf("expected three jobs to be accepted, got %d", accepted)
+	}
+}
+
+func TestSignature(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		req    *http.Request
+		secret string
+	}{
+		{"wrong secret", signedRequest("/slack-event", testSlackChallenge, "not-the-secret", time.Now()), testSigningSecret},
+		{"stale timestamp", signedRequest("/slack-event", testSlackChallenge, testSigningSecret, time.Now().Add(-10*time.Minute)), testSigningSecret},
+		{"unsigned", httptest.NewRequest(http.MethodPost, "/slack-event", strings.NewReader(testSlackChallenge)), testSigningSecret},
+		{"no secret configured", signedRequest("/slack-event", testSlackChallenge, "", time.Now()), ""},
+	} {
+		api := &Api{Log: l, Database: suite.TestDatabase, signingSecret: tc.secret}
+		w := httptest.NewRecorder()
+		api.verified(api.slackEvent)(w, tc.req)
+		res := w.Result()
+		data, err := testsuite.GetBody(res)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.StatusCode != http.StatusUnauthorized {
+			t.Errorf("expected a request with %s to be rejected, got status %d", tc.name, res.StatusCode)
+		}
+		err = testsuite.EvaluateResult(data, map[string]interface{}{
+			"error":   true,
+			"message": "invalid request signature",
+		})
+		if err != nil {
+			t.Error(err)
+		}
+	}
+	req := signedRequest("/slack-event", testSlackChallenge, testSigningSecret, time.Now())
+	req.Body = ioutil.NopCloser(strings.NewReader(strings.Replace(testSlackChallenge, "url_verification", "event_callback", 1)))
+	w := httptest.NewRecorder()
+	a.verified(a.slackEvent)(w, req)
+	if w.Result().StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected a request with a tampered body to be rejected")
+	}
+}
+
 func TestError(t *testing.T) {
 	req := httptest.NewRequest(http.MethodGet, "/slack-event", nil)
 	w := httptest.NewRecorder()
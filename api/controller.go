@@ -4,6 +4,10 @@ import "net/http"
 
 // controller defines the available routes and the functions that handle them
 func (a *Api) controller() {
-	http.HandleFunc("/slack-event", a.slackEvent)
+	http.HandleFunc("/slack-event", a.verified(a.slackEvent))
+	http.HandleFunc("/slack-command", a.verified(a.slackCommand))
+	http.HandleFunc("/slack-interactive", a.verified(a.slackInteractive))
 	http.HandleFunc("/ping", a.ping)
+	http.HandleFunc("/deliveries/failed", a.verified(a.failedDeliveries))
+	http.HandleFunc("/metrics", a.metrics)
 }
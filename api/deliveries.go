@@ -0,0 +1,30 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/blainemoser/todobot/delivery"
+)
+
+// failedDeliveries lists the reminders that couldn't be posted to Slack, for
+// inspection; the messages themselves are left out. As it's only for the
+// bot's operators, requests must be signed with the signing secret the way
+// Slack signs its requests.
+func (a *Api) failedDeliveries(w http.ResponseWriter, r *http.Request) {
+	response := a.NewResponse(w, r)
+	defer response.Respond()
+	response.CheckMethod(http.MethodGet)
+	failures, err := delivery.Failures(response.Database)
+	if err != nil {
+		response.HandleError(http.StatusInternalServerError, "something went wrong", err)
+	}
+	message, err := json.Marshal(map[string]interface{}{
+		"error":    false,
+		"failures": failures,
+	})
+	if err != nil {
+		response.HandleError(http.StatusInternalServerError, "something went wrong", err)
+	}
+	response.message = message
+}
@@ -0,0 +1,50 @@
+package api
+
+import (
+	"fmt"
+	"time"
+)
+
+const claimEvent = `insert ignore into processed_events (event_id) values (?)`
+
+const releaseEvent = `delete from processed_events where event_id = ?`
+
+const expireEvents = `delete from processed_events where created_at < CURRENT_TIMESTAMP - interval ? second`
+
+// processedEventsTTL is how long the IDs of processed events are kept, well
+// beyond the window in which Slack retries
+const processedEventsTTL = 24 * time.Hour
+
+// claim records the event as processed, and reports false if it already was
+// so that Slack's retries of it are ignored
+func (a *Api) claim(eventID string) (bool, error) {
+	result, err := a.Exec(claimEvent, []interface{}{eventID})
+	if err != nil {
+		return false, err
+	}
+	claimed, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return claimed > 0, nil
+}
+
+// release forgets the event, so that a retry of it will be processed
+func (a *Api) release(eventID string) {
+	_, err := a.Exec(releaseEvent, []interface{}{eventID})
+	if err != nil {
+		a.ErrLog(err, false)
+	}
+}
+
+// expireProcessedEvents clears out the IDs of processed events once they're
+// past their TTL, checking every hour
+func (a *Api) expireProcessedEvents() {
+	for {
+		_, err := a.Exec(expireEvents, []interface{}{int64(processedEventsTTL.Seconds())})
+		if err != nil {
+			a.ErrLog(fmt.Errorf("error expiring processed events: %s", err.Error()), false)
+		}
+		time.Sleep(time.Hour)
+	}
+}
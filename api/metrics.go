@@ -0,0 +1,21 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// metrics reports on the processing of Slack events
+func (a *Api) metrics(w http.ResponseWriter, r *http.Request) {
+	response := a.NewResponse(w, r)
+	defer response.Respond()
+	response.CheckMethod(http.MethodGet)
+	message, err := json.Marshal(map[string]interface{}{
+		"error":  false,
+		"events": a.events.metrics(),
+	})
+	if err != nil {
+		response.HandleError(http.StatusInternalServerError, "something went wrong", err)
+	}
+	response.message = message
+}
@@ -0,0 +1,68 @@
+package api
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// maxRequestAge is how old a signed request from Slack may be before it's
+// treated as a replay
+const maxRequestAge = 5 * time.Minute
+
+// verified checks the signature of requests from Slack before passing them on
+// to the handler; every Slack endpoint is wrapped in it
+func (a *Api) verified(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		err := a.verify(r)
+		if err != nil {
+			response := a.NewResponse(w, r)
+			defer response.Respond()
+			response.HandleError(http.StatusUnauthorized, "invalid request signature", err)
+		}
+		handler(w, r)
+	}
+}
+
+// verify follows https://api.slack.com/authentication/verifying-requests-from-slack,
+// and leaves the body in place to be read again
+func (a *Api) verify(r *http.Request) error {
+	if len(a.signingSecret) < 1 {
+		return fmt.Errorf("no signing secret is configured")
+	}
+	timestamp := r.Header.Get("X-Slack-Request-Timestamp")
+	sent, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid request timestamp '%s'", timestamp)
+	}
+	age := time.Since(time.Unix(sent, 0))
+	if age > maxRequestAge || age < -maxRequestAge {
+		return fmt.Errorf("request timestamp %d is stale", sent)
+	}
+	if r.Body == nil {
+		return fmt.Errorf("request has no body")
+	}
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	r.Body.Close()
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	if !hmac.Equal([]byte(r.Header.Get("X-Slack-Signature")), []byte(a.sign(timestamp, body))) {
+		return fmt.Errorf("request signature does not match")
+	}
+	return nil
+}
+
+func (a *Api) sign(timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(a.signingSecret))
+	mac.Write([]byte(fmt.Sprintf("v0:%s:", timestamp)))
+	mac.Write(body)
+	return fmt.Sprintf("v0=%s", hex.EncodeToString(mac.Sum(nil)))
+}
@@ -0,0 +1,102 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/blainemoser/todobot/event"
+	"github.com/blainemoser/todobot/slackapi"
+)
+
+const commandUsage = "Try `/todo add remind me to water the plants every Monday`, `/todo list`, `/todo done 2`, `/todo update 2 every day at 9am` or `/todo snooze 30 minutes`"
+
+// slackCommand handles the /todo slash command, which lets users manage their
+// reminders without mentioning the bot in a channel
+func (a *Api) slackCommand(w http.ResponseWriter, r *http.Request) {
+	response := a.NewResponse(w, r)
+	defer response.Respond()
+	response.CheckMethod(http.MethodPost)
+	body := response.getRequestBody()
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		response.HandleError(http.StatusBadRequest, "invalid request body", err)
+	}
+	response.newSlackCommand(form)
+}
+
+// newSlackCommand acknowledges the command straight away; the reply is sent
+// to its response_url once it's been processed
+func (r *Response) newSlackCommand(form url.Values) {
+	uhash := form.Get("user_id")
+	channel := form.Get("channel_id")
+	text := form.Get("text")
+	responseURL := form.Get("response_url")
+	if len(uhash) < 1 || len(responseURL) < 1 {
+		r.HandleError(http.StatusBadRequest, "invalid slash command", fmt.Errorf("slash command is missing user_id or response_url"))
+	}
+	if !r.events.submit(func() error { return r.processSlackCommand(uhash, channel, text, responseURL) }) {
+		err := fmt.Errorf("slash command rejected, the backlog of %d events is full", eventBacklog)
+		r.HandleError(http.StatusServiceUnavailable, "too many events, try again later", err)
+	}
+	r.message = []byte(`{"response_type": "ephemeral", "text": "Just a moment..."}`)
+}
+
+func (a *Api) processSlackCommand(uhash, channel, text, responseURL string) error {
+	reply, err := a.commandReply(uhash, channel, text)
+	if err != nil {
+		reply = "Sorry, something went wrong..."
+	}
+	respondErr := slackapi.Slack(a.Database, a.slackToken).Respond(responseURL, reply)
+	if err != nil {
+		return err
+	}
+	return respondErr
+}
+
+// commandReply passes the command on to the event logic, phrased as it would
+// be in a mention
+func (a *Api) commandReply(uhash, channel, text string) (string, error) {
+	verb, rest := splitCommand(text)
+	switch verb {
+	case "add":
+		if len(rest) < 1 {
+			return commandUsage, nil
+		}
+		if !strings.Contains(strings.ToLower(rest), "remind") {
+			rest = fmt.Sprintf("remind me to %s", rest)
+		}
+	case "list":
+		rest = "list"
+	case "done":
+		if len(rest) < 1 {
+			return commandUsage, nil
+		}
+		rest = fmt.Sprintf("done %s", rest)
+	case "update", "new":
+		// on its own, this answers the question of whether a reminder was
+		// meant to update another
+		rest = strings.TrimSpace(fmt.Sprintf("%s %s", verb, rest))
+	case "snooze":
+		rest = strings.TrimSpace(fmt.Sprintf("snooze %s", rest))
+	default:
+		return commandUsage, nil
+	}
+	e, err := event.CreateFromCommand(a.Database, a.slackToken, uhash, channel, rest)
+	if err != nil {
+		return "", err
+	}
+	if e == nil {
+		return commandUsage, nil
+	}
+	return fmt.Sprintf("%s%s", e.Message(), e.MessageBody()), nil
+}
+
+func splitCommand(text string) (verb, rest string) {
+	fields := strings.Fields(text)
+	if len(fields) < 1 {
+		return "", ""
+	}
+	return strings.ToLower(fields[0]), strings.Join(fields[1:], " ")
+}
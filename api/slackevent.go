@@ -6,8 +6,9 @@ import (
 	"strings"
 
 	jsonextract "github.com/blainemoser/JsonExtract"
-	slackresponse "github.com/blainemoser/slackResponse"
+	utils "github.com/blainemoser/goutils"
 	"github.com/blainemoser/todobot/event"
+	"github.com/blainemoser/todobot/slackapi"
 )
 
 var (
@@ -74,30 +75,69 @@ func (r *Response) handleSlackEvent(body []byte) {
 			return
 		}
 	}
-	r.newSlackEvent(body)
+	eventID := utils.StringInterface(r.extractOptional(extract, "event_id"))
+	if r.isDuplicate(eventID) {
+		r.message = []byte(`{"error": false, "message": "already processed"}`)
+		return
+	}
+	r.newSlackEvent(body, eventID)
 }
 
-func (r *Response) newSlackEvent(body []byte) {
-	e, err := event.Create(string(body), r.Database, r.slackToken)
+// isDuplicate reports whether the event has already been received, as it will
+// have been when Slack retries one it thinks timed out
+func (r *Response) isDuplicate(eventID string) bool {
+	if len(eventID) < 1 {
+		return false
+	}
+	if retry := r.r.Header.Get("X-Slack-Retry-Num"); len(retry) > 0 {
+		r.Write(fmt.Sprintf("slack retry %s of event %s (%s)", retry, eventID, r.r.Header.Get("X-Slack-Retry-Reason")), "INFO")
+	}
+	claimed, err := r.claim(eventID)
 	if err != nil {
 		r.HandleError(http.StatusInternalServerError, "something went wrong", err)
-		return
 	}
-	if e != nil {
-		r.eventResponse(e)
+	return !claimed
+}
+
+func (r *Response) extractOptional(extract jsonextract.JSONExtract, path string) interface{} {
+	result, err := extract.Extract(path)
+	if err != nil {
+		return nil
 	}
+	return result
 }
 
-func (r *Response) eventResponse(e *event.Event) {
-	err := slackresponse.SlackPost(
+// newSlackEvent acknowledges the event straight away, leaving it to be
+// processed in the background
+func (r *Response) newSlackEvent(body []byte, eventID string) {
+	if !r.events.submit(func() error { return r.processSlackEvent(body) }) {
+		if len(eventID) > 0 {
+			r.release(eventID)
+		}
+		err := fmt.Errorf("slack event rejected, the backlog of %d events is full", eventBacklog)
+		r.HandleError(http.StatusServiceUnavailable, "too many events, try again later", err)
+	}
+	r.message = []byte(`{"error": false, "message": "accepted"}`)
+}
+
+func (a *Api) processSlackEvent(body []byte) error {
+	e, err := event.Create(string(body), a.Database, a.slackToken)
+	if err != nil {
+		return err
+	}
+	if e == nil {
+		return nil
+	}
+	return a.eventResponse(e)
+}
+
+func (a *Api) eventResponse(e *event.Event) error {
+	return slackapi.Slack(a.Database, a.slackToken).Send(
+		e.Destination(),
 		fmt.Sprintf("Hi %s, %s", e.UserTag(), e.Message()),
 		e.MessageBody(),
-		"INFO",
-		r.SlackURL,
-		r.Log,
+		nil,
+		a.SlackURL,
+		a.Log,
 	)
-	if err != nil {
-		r.HandleError(http.StatusInternalServerError, "something went wrong", err)
-		return
-	}
 }
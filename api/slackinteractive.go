@@ -0,0 +1,99 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/blainemoser/todobot/event"
+	"github.com/blainemoser/todobot/slackapi"
+)
+
+// interaction is the part of Slack's block_actions payload that's needed to
+// act on a reminder's buttons
+type interaction struct {
+	Type string `json:"type"`
+	User struct {
+		ID string `json:"id"`
+	} `json:"user"`
+	ResponseURL string `json:"response_url"`
+	Actions     []struct {
+		ActionID string `json:"action_id"`
+		Value    string `json:"value"`
+	} `json:"actions"`
+}
+
+// slackInteractive handles the buttons on reminders, which complete, snooze
+// or skip them
+func (a *Api) slackInteractive(w http.ResponseWriter, r *http.Request) {
+	response := a.NewResponse(w, r)
+	defer response.Respond()
+	response.CheckMethod(http.MethodPost)
+	body := response.getRequestBody()
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		response.HandleError(http.StatusBadRequest, "invalid request body", err)
+	}
+	var i interaction
+	err = json.Unmarshal([]byte(form.Get("payload")), &i)
+	if err != nil {
+		response.HandleError(http.StatusBadRequest, "invalid interaction payload", err)
+	}
+	response.newInteraction(&i)
+}
+
+func (r *Response) newInteraction(i *interaction) {
+	if i.Type != "block_actions" || len(i.Actions) < 1 {
+		r.message = []byte(`{"error": false, "message": "ignored"}`)
+		return
+	}
+	if len(i.User.ID) < 1 || len(i.ResponseURL) < 1 {
+		r.HandleError(http.StatusBadRequest, "invalid interaction payload", fmt.Errorf("interaction is missing user or response_url"))
+	}
+	if !r.events.submit(func() error { return r.processInteraction(i) }) {
+		err := fmt.Errorf("interaction rejected, the backlog of %d events is full", eventBacklog)
+		r.HandleError(http.StatusServiceUnavailable, "too many events, try again later", err)
+	}
+	r.message = []byte(`{"error": false, "message": "accepted"}`)
+}
+
+// processInteraction acts on the button that was clicked, and replaces the
+// reminder with what was done about it
+func (a *Api) processInteraction(i *interaction) error {
+	reply, err := a.interactionReply(i.User.ID, i.Actions[0].ActionID, i.Actions[0].Value)
+	if err != nil {
+		reply = "Sorry, something went wrong..."
+	}
+	updateErr := slackapi.Slack(a.Database, a.slackToken).Update(i.ResponseURL, reply)
+	if err != nil {
+		return err
+	}
+	return updateErr
+}
+
+func (a *Api) interactionReply(uhash, action, value string) (string, error) {
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("invalid event id '%s' for action %s", value, action)
+	}
+	var e *event.Event
+	switch action {
+	case "done":
+		e, err = event.Complete(a.Database, uhash, id)
+	case "snooze":
+		e, err = event.Snooze(a.Database, uhash, id, event.SnoozeFor)
+	case "skip":
+		e, err = event.Skip(a.Database, uhash, id)
+	default:
+		return "", fmt.Errorf("unknown action '%s'", action)
+	}
+	if err != nil {
+		return "", err
+	}
+	if e == nil {
+		return "Sorry, I couldn't find that reminder, it may have been removed already", nil
+	}
+	return fmt.Sprintf("%s%s", e.Message(), e.MessageBody()), nil
+}
@@ -0,0 +1,89 @@
+package api
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// eventWorkers is how many Slack events are processed at once, and
+// eventBacklog how many may wait their turn before more are turned away
+const (
+	eventWorkers = 4
+	eventBacklog = 256
+)
+
+// workerPool processes Slack events and commands in the background, so that
+// Slack can be answered well within its three second timeout
+type workerPool struct {
+	jobs      chan func() error
+	pending   sync.WaitGroup
+	onError   func(error)
+	received  int64
+	processed int64
+	failed    int64
+	rejected  int64
+}
+
+func newWorkerPool(workers, backlog int, onError func(error)) *workerPool {
+	p := &workerPool{
+		jobs:    make(chan func() error, backlog),
+		onError: onError,
+	}
+	for i := 0; i < workers; i++ {
+		go p.work()
+	}
+	return p
+}
+
+// submit queues the job, or reports false if the backlog is full
+func (p *workerPool) submit(job func() error) bool {
+	p.pending.Add(1)
+	select {
+	case p.jobs <- job:
+		atomic.AddInt64(&p.received, 1)
+		return true
+	default:
+		p.pending.Done()
+		atomic.AddInt64(&p.rejected, 1)
+		return false
+	}
+}
+
+func (p *workerPool) work() {
+	for job := range p.jobs {
+		err := p.run(job)
+		if err != nil {
+			atomic.AddInt64(&p.failed, 1)
+			p.onError(err)
+		} else {
+			atomic.AddInt64(&p.processed, 1)
+		}
+		p.pending.Done()
+	}
+}
+
+// run keeps a panicking job from taking its worker down with it
+func (p *workerPool) run(job func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic processing slack event: %v", r)
+		}
+	}()
+	return job()
+}
+
+// wait blocks until every job submitted so far has been processed
+func (p *workerPool) wait() {
+	p.pending.Wait()
+}
+
+func (p *workerPool) metrics() map[string]int64 {
+	return map[string]int64{
+		"received":  atomic.LoadInt64(&p.received),
+		"processed": atomic.LoadInt64(&p.processed),
+		"failed":    atomic.LoadInt64(&p.failed),
+		"rejected":  atomic.LoadInt64(&p.rejected),
+		"backlog":   int64(len(p.jobs)),
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/blainemoser/todobot/event"
+)
+
+// commands are run from the command line instead of starting the bot, as
+// "todobot <command> [arguments]"
+var commands = map[string]func(args []string) error{
+	"serve":   serve,
+	"migrate": migrations,
+	"users":   users,
+	"events":  events,
+	"export":  export,
+	"config":  config,
+	"parse":   parse,
+}
+
+func runCommand(name string, args []string) error {
+	command, ok := commands[name]
+	if !ok {
+		names := make([]string, 0)
+		for k := range commands {
+			names = append(names, k)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("unknown command '%s', expected one of %s", name, strings.Join(names, ", "))
+	}
+	return command(args)
+}
+
+// parseFlags parses the flags, which may come before or after the other
+// arguments, and returns the other arguments
+func parseFlags(flags *flag.FlagSet, args []string) ([]string, error) {
+	rest := make([]string, 0)
+	for {
+		err := flags.Parse(args)
+		if err != nil {
+			return nil, err
+		}
+		if flags.NArg() < 1 {
+			return rest, nil
+		}
+		rest = append(rest, flags.Arg(0))
+		args = flags.Args()[1:]
+	}
+}
+
+// parse shows what the bot makes of a message without a database or Slack,
+// for trying out phrasing: todobot parse "remind me to water the plants every
+// 2 days at 8am" --tz Africa/Harare --now "2022-01-30 03:25"
+func parse(args []string) error {
+	flags := flag.NewFlagSet("parse", flag.ContinueOnError)
+	tz := flags.String("tz", "UTC", "the time zone of the user sending the message")
+	at := flags.String("now", "", "the time the message is sent, as \"2006-01-02 15:04\" in the time zone or RFC 3339; it's the current time by default")
+	count := flags.Int("n", 5, "how many of the times the reminder fires to show")
+	rest, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(rest) < 1 {
+		return fmt.Errorf("expected a message to parse, such as todobot parse \"remind me to stretch every hour\"")
+	}
+	loc, err := time.LoadLocation(*tz)
+	if err != nil {
+		return err
+	}
+	sent, err := parseTime(*at, loc)
+	if err != nil {
+		return err
+	}
+	p, err := event.PreviewMessage(strings.Join(rest, " "), *tz, sent, *count)
+	if err != nil {
+		return err
+	}
+	printPreview(os.Stdout, p, sent)
+	return nil
+}
+
+func parseTime(value string, loc *time.Location) (time.Time, error) {
+	if len(value) < 1 {
+		return time.Now().In(loc), nil
+	}
+	for _, layout := range []string{"2006-01-02 15:04", "2006-01-02 15:04:05", "2006-01-02T15:04"} {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t, nil
+		}
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("expected --now to be a time such as \"2006-01-02 15:04\", got '%s'", value)
+	}
+	return t.In(loc), nil
+}
+
+func printPreview(w io.Writer, p *event.Preview, sent time.Time) {
+	const timeFormat = "Monday 2 January 2006 at 3:04 pm"
+	fmt.Fprintf(w, "intent:    %s\n", p.Command.Intent)
+	if len(p.Command.Reference) > 0 {
+		fmt.Fprintf(w, "reference: %s\n", p.Command.Reference)
+	}
+	if len(p.Command.Text) > 0 {
+		fmt.Fprintf(w, "text:      %s\n", p.Command.Text)
+	}
+	if len(p.Task) > 0 {
+		fmt.Fprintf(w, "task:      %s\n", p.Task)
+	}
+	fmt.Fprintf(w, "timezone:  %s (UTC%s)\n", p.Location, sent.Format("-07:00"))
+	fmt.Fprintf(w, "now:       %s\n", sent.Format(timeFormat))
+	if p.Command.Intent != event.IntentAdd && p.Command.Intent != event.IntentUpdate {
+		return
+	}
+	if len(p.Schedule) < 1 {
+		fmt.Fprintln(w, "schedule:  none, the reminder couldn't be understood")
+		return
+	}
+	fmt.Fprintf(w, "schedule:  %s\n", p.Schedule)
+	for i, next := range p.Next {
+		label := ""
+		if i == 0 {
+			label = "next:"
+		}
+		fmt.Fprintf(w, "%-10s %s\n", label, next.Format(timeFormat))
+	}
+}
@@ -0,0 +1,280 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	jsonextract "github.com/blainemoser/JsonExtract"
+	utils "github.com/blainemoser/goutils"
+	"github.com/blainemoser/todobot/event"
+)
+
+// setting is one of the bot's configs. Each is layered: its default, then
+// the value in its config file, then its environment variable, then its flag,
+// with the last of them that's set winning.
+type setting struct {
+	name string
+	// file is the config file the setting is read from, envFile or dbFile,
+	// and key its key there
+	file     string
+	key      string
+	env      string
+	fallback string
+	required bool
+	// secret settings are hidden by config print --redacted
+	secret bool
+	// group is what the setting is used for, so that a command checks only
+	// the settings it uses
+	group string
+}
+
+const (
+	slackGroup    = "slack"
+	botGroup      = "bot"
+	databaseGroup = "database"
+)
+
+// serveGroups are the settings the bot itself uses, which are all of them;
+// the admin commands only use the database's
+var serveGroups = []string{slackGroup, botGroup, databaseGroup}
+
+const (
+	envFile = "env.json"
+	dbFile  = migrationsDir + "/configs.json"
+)
+
+// configFile is where settings are read from, which can be moved by its
+// environment variable or flag
+type configFile struct {
+	path string
+	env  string
+	flag string
+}
+
+var configFiles = []configFile{
+	{path: envFile, env: "TODOBOT_CONFIG", flag: "config"},
+	{path: dbFile, env: "TODOBOT_DB_CONFIG", flag: "db-config"},
+}
+
+var settings = []setting{
+	{name: "slackURL", file: envFile, key: "slackURL", env: "TODOBOT_SLACK_URL", required: true, secret: true, group: slackGroup},
+	{name: "slackToken", file: envFile, key: "slackToken", env: "TODOBOT_SLACK_TOKEN", required: true, secret: true, group: slackGroup},
+	{name: "signingSecret", file: envFile, key: "signingSecret", env: "TODOBOT_SIGNING_SECRET", required: true, secret: true, group: slackGroup},
+	{name: "catchUp", file: envFile, key: "catchUp", env: "TODOBOT_CATCH_UP", fallback: event.CatchUpOnce, group: botGroup},
+	{name: "synonyms", file: envFile, key: "synonyms", env: "TODOBOT_SYNONYMS", group: botGroup},
+	{name: "port", file: envFile, key: "port", env: "TODOBOT_PORT", fallback: defaultPort, group: botGroup},
+	{name: "migrations", file: envFile, key: "migrations", env: "TODOBOT_MIGRATIONS", fallback: migrationsDir, group: databaseGroup},
+	{name: "dbHost", file: dbFile, key: "host", env: "DB_HOST", fallback: "127.0.0.1", group: databaseGroup},
+	{name: "dbPort", file: dbFile, key: "port", env: "DB_PORT", fallback: "3306", group: databaseGroup},
+	{name: "dbDatabase", file: dbFile, key: "database", env: "DB_DATABASE", required: true, group: databaseGroup},
+	{name: "dbUsername", file: dbFile, key: "username", env: "DB_USERNAME", required: true, group: databaseGroup},
+	{name: "dbPassword", file: dbFile, key: "password", env: "DB_PASSWORD", secret: true, group: databaseGroup},
+	{name: "dbDriver", file: dbFile, key: "driver", env: "DB_DRIVER", fallback: "mysql", group: databaseGroup},
+}
+
+// flagName is the setting's flag, "--slack-token" for slackToken
+func (s setting) flagName() string {
+	var b strings.Builder
+	for i, r := range s.name {
+		if r >= 'A' && r <= 'Z' {
+			if i > 0 && (s.name[i-1] < 'A' || s.name[i-1] > 'Z') {
+				b.WriteByte('-')
+			}
+			r += 'a' - 'A'
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// configFlags makes the flag set for a command that needs the configs, with
+// a flag for each setting and config file
+func configFlags(command string) *flag.FlagSet {
+	flags := flag.NewFlagSet(command, flag.ContinueOnError)
+	for _, f := range configFiles {
+		flags.String(f.flag, f.path, fmt.Sprintf("the config file, or set %s", f.env))
+	}
+	for _, s := range settings {
+		flags.String(s.flagName(), s.fallback, fmt.Sprintf("%s in %s, or set %s", s.key, s.file, s.env))
+	}
+	return flags
+}
+
+// loadConfig layers the settings, and returns them with where each was set
+func loadConfig(flags *flag.FlagSet) (values, sources map[string]string, err error) {
+	set := make(map[string]string)
+	flags.Visit(func(f *flag.Flag) {
+		set[f.Name] = f.Value.String()
+	})
+	files := make(map[string]map[string]interface{})
+	for _, f := range configFiles {
+		path, explicit := f.path, false
+		if v := os.Getenv(f.env); len(v) > 0 {
+			path, explicit = v, true
+		}
+		if v, ok := set[f.flag]; ok {
+			path, explicit = v, true
+		}
+		files[f.path], err = readConfigFile(path, explicit)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	values = make(map[string]string)
+	sources = make(map[string]string)
+	for _, s := range settings {
+		values[s.name], sources[s.name] = s.fallback, "default"
+		if v, ok := files[s.file][s.key]; ok {
+			values[s.name], sources[s.name] = configString(v), s.file
+		}
+		if v := os.Getenv(s.env); len(v) > 0 {
+			values[s.name], sources[s.name] = v, s.env
+		}
+		if v, ok := set[s.flagName()]; ok {
+			values[s.name], sources[s.name] = v, "--"+s.flagName()
+		}
+	}
+	return values, sources, nil
+}
+
+// readConfigFile reads the settings in the config file; it's fine for the
+// file not to exist unless it was asked for, as the settings can all be given
+// by environment variables instead
+func readConfigFile(path string, explicit bool) (map[string]interface{}, error) {
+	result := make(map[string]interface{})
+	raw, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) && !explicit {
+		return result, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	configs := jsonextract.JSONExtract{
+		RawJSON: string(raw),
+	}
+	for _, s := range settings {
+		v, err := configs.Extract(s.key)
+		if err == nil && v != nil {
+			result[s.key] = v
+		}
+	}
+	return result, nil
+}
+
+// configString is a config file's value as a setting; objects such as the
+// synonyms are kept as JSON
+func configString(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	if _, ok := v.(map[string]interface{}); ok {
+		b, err := json.Marshal(v)
+		if err == nil {
+			return string(b)
+		}
+	}
+	return utils.StringInterface(v)
+}
+
+// validateConfig reports every missing or invalid setting in the groups
+// given at once, rather than making them be fixed one at a time
+func validateConfig(values map[string]string, groups ...string) error {
+	checked := make(map[string]bool)
+	for _, s := range settings {
+		for _, g := range groups {
+			checked[s.name] = checked[s.name] || s.group == g
+		}
+	}
+	missing := make([]string, 0)
+	invalid := make([]string, 0)
+	for _, s := range settings {
+		if checked[s.name] && s.required && len(values[s.name]) < 1 {
+			missing = append(missing, fmt.Sprintf("%s (%s in %s, %s or --%s)", s.name, s.key, s.file, s.env, s.flagName()))
+		}
+	}
+	for _, name := range []string{"port", "dbPort"} {
+		if checked[name] && !isPort(values[name]) {
+			invalid = append(invalid, fmt.Sprintf("%s should be a port number, got '%s'", name, values[name]))
+		}
+	}
+	if _, err := configSynonyms(values["synonyms"]); checked["synonyms"] && err != nil {
+		invalid = append(invalid, err.Error())
+	}
+	switch values["catchUp"] {
+	case event.CatchUpOnce, event.CatchUpAll, event.CatchUpSkip:
+	default:
+		if checked["catchUp"] {
+			invalid = append(invalid, fmt.Sprintf(
+				"catchUp should be one of %s, %s or %s, got '%s'",
+				event.CatchUpOnce, event.CatchUpAll, event.CatchUpSkip, values["catchUp"],
+			))
+		}
+	}
+	errs := make([]string, 0)
+	if len(missing) > 0 {
+		errs = append(errs, fmt.Sprintf("missing configs: %s", strings.Join(missing, ", ")))
+	}
+	if len(invalid) > 0 {
+		errs = append(errs, fmt.Sprintf("invalid configs: %s", strings.Join(invalid, ", ")))
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf(strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// configSynonyms reads the synonyms setting, an object such as {"ring":
+// "call"}
+func configSynonyms(value string) (map[string]string, error) {
+	words := make(map[string]string)
+	if len(value) < 1 {
+		return words, nil
+	}
+	err := json.Unmarshal([]byte(value), &words)
+	if err != nil {
+		return nil, fmt.Errorf("expected synonyms to be an object of words and what they mean")
+	}
+	return words, nil
+}
+
+func config(args []string) error {
+	return subcommand("config", args, map[string]func(args []string) error{
+		"print": configPrint,
+	})
+}
+
+// configPrint shows each setting and where it came from, and whether the
+// configs are valid: todobot config print --redacted
+func configPrint(args []string) error {
+	flags := configFlags("config print")
+	redacted := flags.Bool("redacted", false, "hide the values of secrets")
+	_, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+	values, sources, err := loadConfig(flags)
+	if err != nil {
+		return err
+	}
+	printConfig(os.Stdout, values, sources, *redacted)
+	return validateConfig(values, serveGroups...)
+}
+
+func printConfig(w io.Writer, values, sources map[string]string, redacted bool) {
+	for _, s := range settings {
+		value := values[s.name]
+		switch {
+		case len(value) < 1:
+			fmt.Fprintf(w, "%-14s not set\n", s.name)
+			continue
+		case redacted && s.secret:
+			value = "[redacted]"
+		}
+		fmt.Fprintf(w, "%-14s %s (%s)\n", s.name, value, sources[s.name])
+	}
+}
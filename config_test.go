@@ -0,0 +1,190 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFlagName(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		expects string
+	}{
+		{"port", "port"},
+		{"migrations", "migrations"},
+		{"slackURL", "slack-url"},
+		{"slackToken", "slack-token"},
+		{"catchUp", "catch-up"},
+		{"dbHost", "db-host"},
+	} {
+		if got := (setting{name: tc.name}).flagName(); got != tc.expects {
+			t.Errorf("expected the flag for %s to be --%s, got --%s", tc.name, tc.expects, got)
+		}
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	clearConfigEnv(t)
+	dir := t.TempDir()
+	envPath := writeConfigFile(t, dir, "env.json", `{"slackURL": "https://hooks.example.com/file", "slackToken": "file-token", "port": 9000, "synonyms": {"ring": "call"}}`)
+	dbPath := writeConfigFile(t, dir, "configs.json", `{"host": "file-host", "database": "todobot", "username": "bot"}`)
+	t.Setenv("TODOBOT_CONFIG", envPath)
+	t.Setenv("TODOBOT_DB_CONFIG", dbPath)
+	t.Setenv("TODOBOT_SLACK_TOKEN", "env-token")
+	t.Setenv("DB_HOST", "env-host")
+	flags := configFlags("test")
+	_, err := parseFlags(flags, []string{"--db-host", "flag-host"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	values, sources, err := loadConfig(flags)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		name   string
+		value  string
+		source string
+	}{
+		{"dbDriver", "mysql", "default"},
+		{"migrations", migrationsDir, "default"},
+		{"slackURL", "https://hooks.example.com/file", envFile},
+		{"port", "9000", envFile},
+		{"synonyms", `{"ring":"call"}`, envFile},
+		{"dbDatabase", "todobot", dbFile},
+		{"slackToken", "env-token", "TODOBOT_SLACK_TOKEN"},
+		{"dbHost", "flag-host", "--db-host"},
+		{"signingSecret", "", "default"},
+	} {
+		if values[tc.name] != tc.value || sources[tc.name] != tc.source {
+			t.Errorf(
+				"expected %s to be '%s' from %s, got '%s' from %s",
+				tc.name, tc.value, tc.source, values[tc.name], sources[tc.name],
+			)
+		}
+	}
+}
+
+func TestReadConfigFile(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "env.json")
+	result, err := readConfigFile(missing, false)
+	if err != nil || len(result) > 0 {
+		t.Errorf("expected a default config file that isn't there to be skipped, got %v, %v", result, err)
+	}
+	if _, err = readConfigFile(missing, true); err == nil {
+		t.Errorf("expected a config file that was asked for to be required")
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	values := make(map[string]string)
+	for _, s := range settings {
+		values[s.name] = s.fallback
+	}
+	err := validateConfig(values, serveGroups...)
+	if err == nil {
+		t.Fatalf("expected the defaults alone to be invalid")
+	}
+	for _, s := range settings {
+		if got := strings.Contains(err.Error(), s.name+" ("); got != s.required {
+			t.Errorf("expected %s to be reported missing: %v, got '%s'", s.name, s.required, err.Error())
+		}
+	}
+	values["slackURL"] = "https://hooks.example.com"
+	values["slackToken"] = "token"
+	values["signingSecret"] = "secret"
+	values["dbDatabase"] = "todobot"
+	values["dbUsername"] = "bot"
+	if err = validateConfig(values, serveGroups...); err != nil {
+		t.Errorf("expected the configs to be valid, got '%s'", err.Error())
+	}
+	values["port"] = "http"
+	values["catchUp"] = "sometimes"
+	values["synonyms"] = "ring=call"
+	err = validateConfig(values, serveGroups...)
+	if err == nil {
+		t.Fatalf("expected invalid configs to be reported")
+	}
+	for _, expects := range []string{"port should be a port number", "catchUp should be one of", "expected synonyms to be an object"} {
+		if !strings.Contains(err.Error(), expects) {
+			t.Errorf("expected the error to contain '%s', got '%s'", expects, err.Error())
+		}
+	}
+}
+
+func TestValidateConfigGroups(t *testing.T) {
+	values := make(map[string]string)
+	for _, s := range settings {
+		values[s.name] = s.fallback
+	}
+	values["port"] = "http"
+	err := validateConfig(values, databaseGroup)
+	if err == nil {
+		t.Fatalf("expected the database settings to be missing")
+	}
+	if !strings.Contains(err.Error(), "dbDatabase (") || strings.Contains(err.Error(), "slackToken") || strings.Contains(err.Error(), "port should be") {
+		t.Errorf("expected only the database settings to be checked, got '%s'", err.Error())
+	}
+	values["dbDatabase"] = "todobot"
+	values["dbUsername"] = "bot"
+	if err = validateConfig(values, databaseGroup); err != nil {
+		t.Errorf("expected the database commands not to need the bot's settings, got '%s'", err.Error())
+	}
+}
+
+func TestPrintConfig(t *testing.T) {
+	values := map[string]string{
+		"slackToken": "xoxb-secret",
+		"dbPassword": "hunter2",
+		"dbHost":     "db.internal",
+	}
+	sources := map[string]string{
+		"slackToken": "TODOBOT_SLACK_TOKEN",
+		"dbPassword": "DB_PASSWORD",
+		"dbHost":     "--db-host",
+	}
+	var redacted bytes.Buffer
+	printConfig(&redacted, values, sources, true)
+	for _, expects := range []string{
+		"slackToken     [redacted] (TODOBOT_SLACK_TOKEN)",
+		"dbPassword     [redacted] (DB_PASSWORD)",
+		"dbHost         db.internal (--db-host)",
+		"signingSecret  not set",
+	} {
+		if !strings.Contains(redacted.String(), expects) {
+			t.Errorf("expected the redacted configs to contain '%s', got:\n%s", expects, redacted.String())
+		}
+	}
+	for _, secret := range []string{"xoxb-secret", "hunter2"} {
+		if strings.Contains(redacted.String(), secret) {
+			t.Errorf("expected '%s' to be redacted, got:\n%s", secret, redacted.String())
+		}
+	}
+	var plain bytes.Buffer
+	printConfig(&plain, values, sources, false)
+	if !strings.Contains(plain.String(), "xoxb-secret") {
+		t.Errorf("expected secrets to be shown unless redacted, got:\n%s", plain.String())
+	}
+}
+
+// clearConfigEnv stops the environment the tests run in from setting configs
+func clearConfigEnv(t *testing.T) {
+	for _, f := range configFiles {
+		t.Setenv(f.env, "")
+	}
+	for _, s := range settings {
+		t.Setenv(s.env, "")
+	}
+}
+
+func writeConfigFile(t *testing.T, dir, name, contents string) string {
+	path := filepath.Join(dir, name)
+	err := ioutil.WriteFile(path, []byte(contents), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
@@ -0,0 +1,168 @@
+package delivery
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/blainemoser/MySqlDB/database"
+	utils "github.com/blainemoser/goutils"
+)
+
+const newDelivery = `insert into deliveries (event_id, scheduled_at, channel, status, attempts, heading, message) values (?,?,?,?,?,?,?)`
+
+const editDelivery = `update deliveries set status = ?, error = ?, attempts = ?, next_attempt_at = ? where id = ?`
+
+const dueDeliveries = `select * from deliveries where status = ? and next_attempt_at <= ? order by id`
+
+const failedDeliveries = `select * from deliveries where status in (?,?) order by id`
+
+const (
+	Pending   = "pending"
+	Delivered = "delivered"
+	Retrying  = "retrying"
+	Failed    = "failed"
+)
+
+// maxAttempts is how many times a delivery is tried before it's given up on
+const maxAttempts = 6
+
+// the wait before retrying a delivery starts at backoffBase seconds and
+// doubles with each failed attempt, up to backoffCap seconds
+const (
+	backoffBase int64 = 30
+	backoffCap  int64 = 3600
+)
+
+// Delivery is an attempt to post a reminder to Slack
+type Delivery struct {
+	*database.Database `json:"-"`
+	ID                 int64  `json:"id"`
+	EventID            int64  `json:"event_id"`
+	ScheduledAt        int64  `json:"scheduled_at"`
+	Channel            string `json:"channel"`
+	Status             string `json:"status"`
+	Error              string `json:"error"`
+	Attempts           int    `json:"attempts"`
+	NextAttempt        int64  `json:"next_attempt_at"`
+	Heading            string `json:"-"`
+	Message            string `json:"-"`
+}
+
+// Sender posts the delivery's message to its Slack channel
+type Sender func(d *Delivery) error
+
+// Record logs a reminder that's due to be posted to the channel
+func Record(db *database.Database, eventID, scheduledAt int64, channel, heading, message string) (*Delivery, error) {
+	d := &Delivery{
+		Database:    db,
+		EventID:     eventID,
+		ScheduledAt: scheduledAt,
+		Channel:     channel,
+		Status:      Pending,
+		Heading:     heading,
+		Message:     message,
+	}
+	result, err := d.Exec(newDelivery, []interface{}{
+		d.EventID, d.ScheduledAt, d.Channel, d.Status, d.Attempts, d.Heading, d.Message,
+	})
+	if err != nil {
+		return nil, err
+	}
+	d.ID, err = result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// Retry makes another attempt at each of the failed deliveries whose backoff
+// has passed
+func Retry(db *database.Database, send Sender) error {
+	records, err := db.QueryRaw(dueDeliveries, []interface{}{Retrying, time.Now().Unix()})
+	if err != nil {
+		return err
+	}
+	errs := make([]string, 0)
+	for _, rec := range records {
+		err = fromRecord(db, rec).Attempt(send)
+		if err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf(strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// Failures lists the deliveries that are waiting to be retried or have been
+// given up on
+func Failures(db *database.Database) ([]*Delivery, error) {
+	records, err := db.QueryRaw(failedDeliveries, []interface{}{Retrying, Failed})
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*Delivery, 0)
+	for _, rec := range records {
+		result = append(result, fromRecord(db, rec))
+	}
+	return result, nil
+}
+
+// Attempt posts the message, and schedules a retry if that fails
+func (d *Delivery) Attempt(send Sender) error {
+	d.Attempts++
+	sendErr := send(d)
+	switch {
+	case sendErr == nil:
+		d.Status = Delivered
+		d.Error = ""
+		d.NextAttempt = 0
+	case d.Attempts >= maxAttempts:
+		d.Status = Failed
+		d.Error = sendErr.Error()
+		d.NextAttempt = 0
+	default:
+		d.Status = Retrying
+		d.Error = sendErr.Error()
+		d.NextAttempt = time.Now().Unix() + backoff(d.Attempts)
+	}
+	_, err := d.Exec(editDelivery, []interface{}{
+		d.Status, d.Error, d.Attempts, d.NextAttempt, d.ID,
+	})
+	if err != nil {
+		return err
+	}
+	if sendErr != nil {
+		return fmt.Errorf("delivery #%d failed on attempt %d: %s", d.ID, d.Attempts, sendErr.Error())
+	}
+	return nil
+}
+
+func backoff(attempts int) int64 {
+	wait := backoffBase
+	for i := 1; i < attempts && wait < backoffCap; i++ {
+		wait *= 2
+	}
+	if wait > backoffCap {
+		return backoffCap
+	}
+	return wait
+}
+
+func fromRecord(db *database.Database, rec map[string]interface{}) *Delivery {
+	return &Delivery{
+		Database:    db,
+		ID:          utils.Int64Interface(rec["id"]),
+		EventID:     utils.Int64Interface(rec["event_id"]),
+		ScheduledAt: utils.Int64Interface(rec["scheduled_at"]),
+		Channel:     utils.StringInterface(rec["channel"]),
+		Status:      utils.StringInterface(rec["status"]),
+		Error:       utils.StringInterface(rec["error"]),
+		Attempts:    int(utils.Int64Interface(rec["attempts"])),
+		NextAttempt: utils.Int64Interface(rec["next_attempt_at"]),
+		Heading:     utils.StringInterface(rec["heading"]),
+		Message:     utils.StringInterface(rec["message"]),
+	}
+}
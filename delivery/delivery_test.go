@@ -0,0 +1,95 @@
+package delivery
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/blainemoser/todobot/testsuite"
+)
+
+var suite *testsuite.TestSuite
+
+func TestMain(m *testing.M) {
+	var err error
+	suite, err = testsuite.Initialize("delivery")
+	if err != nil {
+		panic(err)
+	}
+	defer suite.TearDown()
+	suite.ResultCode = m.Run()
+}
+
+func TestDelivered(t *testing.T) {
+	d, err := Record(suite.TestDatabase, testEventID(t), time.Now().Unix(), "C02NLG80TEH", "Hi", "\n'stand up'")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = d.Attempt(func(sent *Delivery) error {
+		if sent.Channel != "C02NLG80TEH" || sent.Heading != "Hi" || sent.Message != "\n'stand up'" {
+			return fmt.Errorf("unexpected message '%s %s' to %s", sent.Heading, sent.Message, sent.Channel)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Status != Delivered || d.Attempts != 1 {
+		t.Errorf("expected the delivery to succeed on the first attempt, got '%s' after %d", d.Status, d.Attempts)
+	}
+}
+
+func TestRetries(t *testing.T) {
+	d, err := Record(suite.TestDatabase, testEventID(t), time.Now().Unix(), "C02NLG80TEH", "Hi", "\n'stand up'")
+	if err != nil {
+		t.Fatal(err)
+	}
+	failing := func(sent *Delivery) error {
+		return fmt.Errorf("channel_not_found")
+	}
+	for i := 1; i < maxAttempts; i++ {
+		started := time.Now().Unix()
+		if d.Attempt(failing) == nil {
+			t.Fatalf("expected attempt %d to fail", i)
+		}
+		if d.Status != Retrying || d.Error != "channel_not_found" {
+			t.Errorf("expected attempt %d to be retried, got '%s'", i, d.Status)
+		}
+		if wait := d.NextAttempt - started; wait < backoff(i) || wait > backoff(i)+1 {
+			t.Errorf("expected attempt %d to be retried in %d seconds, got %d", i, backoff(i), wait)
+		}
+	}
+	if d.Attempt(failing) == nil {
+		t.Fatalf("expected the last attempt to fail")
+	}
+	if d.Status != Failed || d.NextAttempt != 0 {
+		t.Errorf("expected the delivery to be given up on after %d attempts, got '%s'", maxAttempts, d.Status)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	for attempts, expects := range map[int]int64{
+		1:  30,
+		2:  60,
+		3:  120,
+		5:  480,
+		8:  3600,
+		40: 3600,
+	} {
+		if wait := backoff(attempts); wait != expects {
+			t.Errorf("expected a backoff of %d seconds after %d attempt(s), got %d", expects, attempts, wait)
+		}
+	}
+}
+
+func testEventID(t *testing.T) int64 {
+	result, err := suite.TestDatabase.Exec("insert into events (etext) values (?)", []interface{}{"remind me to stand up every day"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
@@ -0,0 +1,118 @@
+package event
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/blainemoser/MySqlDB/database"
+)
+
+// SnoozeFor is how long the snooze button puts a reminder off for, in seconds
+const SnoozeFor int64 = 900
+
+// Complete stops the user's event, found by its ID, as "done" would. A nil
+// event is returned if the user has no such event.
+func Complete(db *database.Database, uhash string, id int64) (*Event, error) {
+	Queue.mu.Lock()
+	defer Queue.mu.Unlock()
+	e := Queue.find(uhash, id)
+	if e == nil {
+		return nil, nil
+	}
+	return e.remove()
+}
+
+// Snooze reminds the user of the event again after the given number of
+// seconds, without changing its schedule. One-off events that have already
+// fired are brought back for the purpose.
+func Snooze(db *database.Database, uhash string, id int64, seconds int64) (*Event, error) {
+	Queue.mu.Lock()
+	defer Queue.mu.Unlock()
+	e := Queue.find(uhash, id)
+	if e == nil {
+		e = reopen(db, uhash, id)
+	}
+	if e == nil {
+		return nil, nil
+	}
+	return e.snoozeUntil(now()+seconds, fmt.Sprintf("again in %s", describeSeconds(seconds)))
+}
+
+// Skip passes over the next occurrence of the event; for a one-off event
+// that's the same as completing it
+func Skip(db *database.Database, uhash string, id int64) (*Event, error) {
+	Queue.mu.Lock()
+	defer Queue.mu.Unlock()
+	e := Queue.find(uhash, id)
+	if e == nil {
+		return nil, nil
+	}
+	if e.Once {
+		return e.remove()
+	}
+	if e.Snoozed > 0 {
+		e.Snoozed = 0
+		e.Next = e.resume
+	}
+	e.Timestamp = float64(e.Next)
+	e.setNext()
+	if e.EndsAt > 0 && e.Next > e.EndsAt {
+		e.Schedule = 0
+		e.complete()
+		Queue.remove(e)
+		e.Emessage = "I'll stop reminding you, that was the last one"
+		e.verbatim = true
+		return e, e.save()
+	}
+	e.Emessage = fmt.Sprintf(
+		"again %s, skipping the one before",
+		time.Unix(e.Next, 0).In(e.location()).Format("on Monday 2 January at 3:04 pm"),
+	)
+	e.pushToQueue()
+	return e, e.save()
+}
+
+// reopen loads a one-off event of the user's that has already fired
+func reopen(db *database.Database, uhash string, id int64) *Event {
+	records, err := db.QueryRaw(findEvent, []interface{}{id, uhash})
+	if err != nil {
+		fmt.Println("error finding event", err.Error())
+		return nil
+	}
+	if len(records) < 1 {
+		return nil
+	}
+	e := makeEventFromDBRecord(records[0], db)
+	if e == nil || !e.Once {
+		return nil
+	}
+	_, err = e.Exec(reopenEvent, []interface{}{e.ID})
+	if err != nil {
+		fmt.Println("error reopening event", err.Error())
+		return nil
+	}
+	return e
+}
+
+// processedSnoozed reminds the user again of a snoozed event, which then
+// carries on with its schedule as it was
+func (e *Event) processedSnoozed() map[string]string {
+	e.Snoozed = 0
+	e.Next = e.resume
+	if e.Next <= now() {
+		e.Timestamp = float64(e.Next)
+		e.setNext()
+	}
+	err := e.save()
+	if err != nil {
+		fmt.Println("error saving event", err.Error())
+	}
+	return e.reminder()
+}
+
+func describeSeconds(seconds int64) string {
+	if seconds%int64(hour) == 0 {
+		return fmt.Sprintf("%d hour(s)", seconds/int64(hour))
+	}
+	return fmt.Sprintf("%d minute(s)", seconds/int64(minute))
+}
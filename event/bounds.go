@@ -0,0 +1,190 @@
+package event
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// bounds reads the end conditions of a recurring event: an end date ("until
+// Friday", "until 3 March"), a duration ("for 5 days") or a number of
+// occurrences ("3 times"). A duration or number only ends the event once it's
+// been said how often it happens ("every hour for 5 days"), or as "for the
+// next 5 days", so that "stretch for 5 minutes every hour" and "take 2 pills
+// 3 times a day" are left alone. The words that aren't part of those clauses
+// are returned so that they don't confuse the schedule.
+func (e *Event) bounds(words []string) (endsAt int64, remaining int, kept []string) {
+	kept = make([]string, 0)
+	recurring := false
+	for i := 0; i < len(words); i++ {
+		v := strings.Trim(words[i], punctuation)
+		switch {
+		case v == "until":
+			if ends, n := e.untilDate(words[i+1:]); ends > 0 {
+				endsAt = ends
+				i += n
+				continue
+			}
+		case v == "twice" && recurring:
+			remaining = 2
+			continue
+		case (v == "times" || v == "time") && recurring && len(kept) > 0 && parseNumber(strings.Trim(kept[len(kept)-1], punctuation)) > 0:
+			remaining = parseNumber(strings.Trim(kept[len(kept)-1], punctuation))
+			kept = kept[:len(kept)-1]
+			continue
+		case v == "for" && i+4 < len(words) && words[i+1] == "the" && words[i+2] == "next":
+			if ends := e.forDuration(words[i+3], words[i+4]); ends > 0 {
+				endsAt = ends
+				i += 4
+				continue
+			}
+		case v == "for" && recurring && i+2 < len(words):
+			if ends := e.forDuration(words[i+1], words[i+2]); ends > 0 {
+				endsAt = ends
+				i += 2
+				continue
+			}
+		}
+		recurring = recurring || recurs(v)
+		kept = append(kept, words[i])
+	}
+	return endsAt, remaining, kept
+}
+
+// recurs reports whether the word says how often an event happens: "every",
+// "daily", "mondays", "weekends" and the like
+func recurs(word string) bool {
+	if recurrences[word] {
+		return true
+	}
+	if !strings.HasSuffix(word, "s") {
+		return false
+	}
+	_, weekday := weekdayNames[strings.TrimSuffix(word, "s")]
+	_, group := weekdayGroups[strings.TrimSuffix(word, "s")]
+	return weekday || group
+}
+
+// untilDate finds the date at the start of the clause following "until",
+// and how many of the clause's words it took up; the event runs until the end
+// of that day
+func (e *Event) untilDate(clause []string) (int64, int) {
+	ref := e.reference()
+	words := make([]string, 0)
+	for _, v := range clause {
+		words = append(words, strings.Trim(v, punctuation))
+	}
+	for i, v := range words {
+		if i >= untilWords {
+			break
+		}
+		if v == "today" {
+			return endOfDay(ref), i + 1
+		}
+		if d, ok := weekdayNames[v]; ok {
+			if ref.Weekday() == d {
+				return endOfDay(ref), i + 1
+			}
+			return endOfDay(nextWeekday(ref, d)), i + 1
+		}
+		if date, ok := e.findDate(words, i, v, ref); ok {
+			return endOfDay(date), dateEnd(words, i)
+		}
+	}
+	return 0, 0
+}
+
+// dateEnd is the index of the word after the date findDate found at index i:
+// "next Friday", "3 March 2023"
+func dateEnd(words []string, i int) int {
+	switch words[i] {
+	case "next", "on", "this":
+		return i + 2
+	}
+	if _, ok := months[words[i]]; !ok {
+		return i + 1
+	}
+	_, next := dayAround(words, i)
+	if next < len(words) && len(words[next]) == 4 {
+		if _, err := strconv.Atoi(words[next]); err == nil {
+			next++
+		}
+	}
+	return next
+}
+
+func (e *Event) forDuration(number, unit string) int64 {
+	n := parseNumber(strings.Trim(number, punctuation))
+	u := units[strings.TrimSuffix(strings.Trim(unit, punctuation), "s")]
+	if n < 1 || len(u) < 1 {
+		return 0
+	}
+	ref := e.reference()
+	switch u {
+	case "month":
+		return ref.AddDate(0, n, 0).Unix()
+	case "year":
+		return ref.AddDate(n, 0, 0).Unix()
+	case "week":
+		return ref.AddDate(0, 0, 7*n).Unix()
+	case "day":
+		return ref.AddDate(0, 0, n).Unix()
+	}
+	return ref.Unix() + int64(n)*unitSeconds[u]
+}
+
+// setBounds applies the end conditions asked for to a new or updated event
+func (e *Event) setBounds() error {
+	e.parseBounds()
+	return e.save()
+}
+
+func (e *Event) parseBounds() {
+	e.EndsAt, e.Remaining, _ = e.bounds(sanitize(e.echo()))
+	if e.Schedule < 1 {
+		e.EndsAt = 0
+		e.Remaining = 0
+	}
+	e.Emessage = fmt.Sprintf("%s%s", e.Emessage, e.describeBounds())
+}
+
+func (e *Event) describeBounds() string {
+	description := ""
+	if e.EndsAt > 0 {
+		description = time.Unix(e.EndsAt, 0).In(e.location()).Format(" until Monday 2 January")
+	}
+	if e.Remaining > 0 {
+		description = fmt.Sprintf("%s, %d more time(s)", description, e.Remaining)
+	}
+	return description
+}
+
+// lastOccurrence counts down the remaining occurrences of a bounded event, and
+// reports whether the one being processed is the last
+func (e *Event) lastOccurrence() bool {
+	if e.Remaining > 0 {
+		e.Remaining--
+		if e.Remaining < 1 {
+			return true
+		}
+	}
+	return e.EndsAt > 0 && e.Next > e.EndsAt
+}
+
+func (e *Event) processedLast() map[string]string {
+	e.Schedule = 0
+	e.complete()
+	err := e.save()
+	if err != nil {
+		fmt.Println("error retiring event", err.Error())
+	}
+	return map[string]string{
+		"message": fmt.Sprintf("\n'%s'\nThis was the last reminder, I'll stop reminding you now", e.echo()),
+		"heading": fmt.Sprintf("Hi %s, %s", e.UserTag(), "here's a friendly reminder"),
+	}
+}
+
+func endOfDay(date time.Time) int64 {
+	return time.Date(date.Year(), date.Month(), date.Day(), 23, 59, 59, 0, date.Location()).Unix()
+}
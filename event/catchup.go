@@ -0,0 +1,72 @@
+package event
+
+import "fmt"
+
+// SetCatchUp chooses what happens on boot to the occurrences of events that
+// were missed while the bot was down: they are fired once, all fired, or
+// skipped
+func SetCatchUp(policy string) error {
+	switch policy {
+	case CatchUpOnce, CatchUpAll, CatchUpSkip:
+		catchUp = policy
+		return nil
+	}
+	return fmt.Errorf("unknown catch-up policy '%s', expected one of %s, %s or %s", policy, CatchUpOnce, CatchUpAll, CatchUpSkip)
+}
+
+// catchUp applies the catch-up policy to an event loaded on boot, given the
+// time its next occurrence was due when it was last saved; if that's still to
+// come it's kept, as the stored time is exact where the timestamp the
+// schedule is worked out from may not be. It reports whether the event should
+// still be queued.
+func (e *Event) catchUp(nextAt int64) bool {
+	if e.Snoozed > 0 {
+		return e.catchUpSnoozed()
+	}
+	if e.Once {
+		if e.Next > now() || catchUp != CatchUpSkip {
+			return true
+		}
+		e.complete()
+		return false
+	}
+	if nextAt < 1 {
+		return true
+	}
+	if nextAt > now() {
+		e.Next = nextAt
+		return true
+	}
+	if catchUp == CatchUpSkip {
+		return true
+	}
+	e.Next = nextAt
+	if catchUp == CatchUpAll {
+		e.missed = maxCatchUp - 1
+	}
+	err := e.save()
+	if err != nil {
+		fmt.Println("error saving event", err.Error())
+	}
+	return true
+}
+
+// catchUpSnoozed puts a snoozed event back as it was; a snooze that ran out
+// while the bot was down is treated as a missed occurrence
+func (e *Event) catchUpSnoozed() bool {
+	if e.Snoozed <= now() && catchUp == CatchUpSkip {
+		e.Snoozed = 0
+		if e.Once {
+			e.complete()
+			return false
+		}
+	} else {
+		e.resume = e.Next
+		e.Next = e.Snoozed
+	}
+	err := e.save()
+	if err != nil {
+		fmt.Println("error saving event", err.Error())
+	}
+	return true
+}
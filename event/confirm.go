@@ -0,0 +1,68 @@
+package event
+
+import "fmt"
+
+// confirmation is a new reminder waiting on the user to say whether it should
+// update one they already have or be created alongside it
+type confirmation struct {
+	request *EventInit
+	eventID int64
+	asked   int64
+}
+
+// pending holds each user's unanswered confirmation; like the Users map it's
+// guarded by the queue's lock
+var pending = make(map[string]*confirmation)
+
+// askToUpdate checks a new reminder against the user's others, and if one of
+// them looks like the same task asks whether to update it rather than
+// guessing: "remind me to call mom every day" may or may not be meant to
+// replace "remind me to call the dentist every day"
+func (ei *EventInit) askToUpdate() *Event {
+	candidates := matching(ei.User, normalize(ei.task()), (*Event).task)
+	if len(candidates) < 1 {
+		return nil
+	}
+	e := candidates[0]
+	pending[ei.User] = &confirmation{
+		request: ei,
+		eventID: e.ID,
+		asked:   now(),
+	}
+	return ei.sorry(fmt.Sprintf(
+		"Did you mean to update '%s' (#%d) or create a new reminder? Reply `update` or `new`",
+		e.task(), e.ID,
+	))
+}
+
+// confirm acts on the user's answer to askToUpdate: whether to update the
+// reminder asked about
+func (ei *EventInit) confirm(answer bool) (*Event, error) {
+	c := pending[ei.User]
+	if c == nil {
+		return ei.help(IntentConfirm), nil
+	}
+	delete(pending, ei.User)
+	if now()-c.asked > confirmFor {
+		return ei.sorry("Sorry, I've forgotten what that was about, please ask me again"), nil
+	}
+	request := c.request
+	request.user = ei.user
+	if !answer {
+		e, err := request.create()
+		if err != nil {
+			return nil, err
+		}
+		return request.setSchedule(e)
+	}
+	e := Queue.find(ei.User, c.eventID)
+	if e == nil {
+		return ei.sorry(fmt.Sprintf("Sorry, there's no reminder #%d on your todo list any more", c.eventID)), nil
+	}
+	return request.handleExisting(e)
+}
+
+// task is what the user is asking to be reminded of; see Event.task
+func (ei *EventInit) task() string {
+	return (&Event{Etext: ei.Etext}).task()
+}
@@ -0,0 +1,215 @@
+package event
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type cronSchedule struct {
+	minutes map[int]bool
+	hours   map[int]bool
+	doms    map[int]bool
+	months  map[int]bool
+	dows    map[int]bool
+	domAny  bool
+	dowAny  bool
+}
+
+type cronField struct {
+	min   int
+	max   int
+	names map[string]int
+}
+
+// parseCron looks for a cron expression, as in `remind me to rotate keys cron
+// "0 9 * * 1-5"`, which then decides the schedule of the event. An unquoted
+// expression is only taken for one if it parses, so that "fix the cron job
+// every day" is left to the other schedules, while a quoted one that doesn't
+// parse gets the reason back.
+func (e *Event) parseCron() bool {
+	e.Cron = ""
+	e.cron = nil
+	match := cronExpression.FindStringSubmatch(e.echo())
+	if len(match) < 3 {
+		return false
+	}
+	quoted := len(match[1]) > 0
+	fields := strings.Fields(strings.ToLower(match[2]))
+	if !quoted {
+		fields = leadingCronFields(fields)
+	}
+	expr := strings.Join(fields, " ")
+	c, err := parseCron(expr)
+	if err != nil && !quoted {
+		return false
+	}
+	if err != nil {
+		e.Schedule = 0
+		e.Emessage = fmt.Sprintf("Sorry, I couldn't read that cron schedule: %s", err.Error())
+		e.verbatim = true
+		return true
+	}
+	e.Cron = expr
+	e.cron = c
+	e.Schedule = int64(minute)
+	e.Emessage = fmt.Sprintf("on the cron schedule \"%s\"", expr)
+	return true
+}
+
+// leadingCronFields is as much of the words following "cron" as could be an
+// expression: a macro such as "@daily", or five fields
+func leadingCronFields(fields []string) []string {
+	if len(fields) > 0 && strings.HasPrefix(fields[0], "@") {
+		return fields[:1]
+	}
+	if len(fields) > len(cronFields) {
+		return fields[:len(cronFields)]
+	}
+	return fields
+}
+
+func parseCron(expr string) (*cronSchedule, error) {
+	if macro, ok := cronMacros[expr]; ok {
+		expr = macro
+	}
+	fields := strings.Fields(expr)
+	if len(fields) != len(cronFields) {
+		return nil, fmt.Errorf("expected %d fields in cron expression '%s', got %d", len(cronFields), expr, len(fields))
+	}
+	sets := make([]map[int]bool, len(fields))
+	for i, field := range fields {
+		set, err := parseCronField(field, cronFields[i])
+		if err != nil {
+			return nil, err
+		}
+		sets[i] = set
+	}
+	if sets[4][7] {
+		// both 0 and 7 are Sunday
+		sets[4][0] = true
+	}
+	return &cronSchedule{
+		minutes: sets[0],
+		hours:   sets[1],
+		doms:    sets[2],
+		months:  sets[3],
+		dows:    sets[4],
+		domAny:  fields[2] == "*",
+		dowAny:  fields[4] == "*",
+	}, nil
+}
+
+// parseCronField reads a single field, made up of comma separated values,
+// ranges ("1-5"), wildcards and steps ("*/15", "0-30/10")
+func parseCronField(field string, spec cronField) (map[int]bool, error) {
+	set := make(map[int]bool)
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if i := strings.Index(part, "/"); i > -1 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("invalid step in cron field '%s'", field)
+			}
+			step = n
+			part = part[:i]
+		}
+		from, to, err := cronRange(part, spec)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron field '%s': %s", field, err.Error())
+		}
+		if step > 1 && from == to {
+			to = spec.max
+		}
+		for v := from; v <= to; v += step {
+			set[v] = true
+		}
+	}
+	return set, nil
+}
+
+func cronRange(part string, spec cronField) (int, int, error) {
+	if part == "*" {
+		return spec.min, spec.max, nil
+	}
+	bounds := strings.SplitN(part, "-", 2)
+	from, err := cronValue(bounds[0], spec)
+	if err != nil {
+		return 0, 0, err
+	}
+	if len(bounds) < 2 {
+		return from, from, nil
+	}
+	to, err := cronValue(bounds[1], spec)
+	if err != nil {
+		return 0, 0, err
+	}
+	if to < from {
+		return 0, 0, fmt.Errorf("range %s is backwards", part)
+	}
+	return from, to, nil
+}
+
+func cronValue(v string, spec cronField) (int, error) {
+	if n, ok := spec.names[v]; ok {
+		return n, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, fmt.Errorf("'%s' is not a number", v)
+	}
+	if n < spec.min || n > spec.max {
+		return 0, fmt.Errorf("%d is out of range (%d-%d)", n, spec.min, spec.max)
+	}
+	return n, nil
+}
+
+// next returns the first time after t that matches the schedule, in t's
+// location, or the zero time if nothing matches within five years
+func (c *cronSchedule) next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		y, m, d := t.Date()
+		switch {
+		case !c.months[int(m)]:
+			t = advance(t, time.Date(y, m+1, 1, 0, 0, 0, 0, loc))
+		case !c.dayMatches(t):
+			t = advance(t, time.Date(y, m, d+1, 0, 0, 0, 0, loc))
+		case !c.hours[t.Hour()]:
+			t = advance(t, time.Date(y, m, d, t.Hour()+1, 0, 0, 0, loc))
+		case !c.minutes[t.Minute()]:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// dayMatches follows cron in matching either the day of the month or the day
+// of the week when both are restricted
+func (c *cronSchedule) dayMatches(t time.Time) bool {
+	dom := c.doms[t.Day()]
+	dow := c.dows[int(t.Weekday())]
+	switch {
+	case c.domAny && c.dowAny:
+		return true
+	case c.domAny:
+		return dow
+	case c.dowAny:
+		return dom
+	}
+	return dom || dow
+}
+
+// advance guards against wall clock changes that would otherwise leave the
+// search standing still
+func advance(from, to time.Time) time.Time {
+	if !to.After(from) {
+		return from.Add(time.Minute)
+	}
+	return to
+}
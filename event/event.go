@@ -1,7 +1,6 @@
 package event
 
 import (
-	"container/list"
 	"fmt"
 	"strconv"
 	"strings"
@@ -26,8 +25,26 @@ type Event struct {
 	Etype     string
 	Emessage  string
 	Next      int64
+	Once      bool
+	Cron      string
+	EndsAt    int64
+	Remaining int
+	LastFired int64
+	Snoozed   int64
 	text      []string
 	nextSet   bool
+	weekdays  map[time.Weekday]bool
+	months    int
+	month     time.Month
+	monthDay  int
+	cron      *cronSchedule
+	index     int
+	missed    int
+	resume    int64
+	summary   string
+	intent    Intent
+	verbatim  bool
+	asOf      int64
 }
 
 type EventInit struct {
@@ -39,20 +56,28 @@ type EventInit struct {
 	Etext      string
 	Etype      string
 	User       string
+	user       *user.User
 }
 
 func ProcessQueue(result chan []map[string]string) {
-	if Queue == nil || Queue.Len() < 1 {
+	if Queue == nil {
 		result <- []map[string]string{}
 		return
 	}
-	now := time.Now().Unix() + 5
+	Queue.mu.Lock()
+	defer Queue.mu.Unlock()
+	now := time.Now().Unix() + lookahead
 	set := make([]map[string]string, 0)
-	for i := Queue.Front(); i != nil; i = i.Next() {
-		if k, ok := i.Value.(*Event); ok {
-			if k.Next <= now {
-				set = append(set, k.Processed())
-			}
+	for _, k := range Queue.due(now) {
+		scheduled := k.Next
+		processed := k.Processed()
+		processed["event"] = strconv.FormatInt(k.ID, 10)
+		processed["channel"] = k.Destination()
+		processed["scheduled"] = strconv.FormatInt(scheduled, 10)
+		set = append(set, processed)
+		if k.Next > 0 {
+			// one-off and retired events are done once they've been processed
+			Queue.push(k)
 		}
 	}
 	result <- set
@@ -60,8 +85,10 @@ func ProcessQueue(result chan []map[string]string) {
 
 func ClearQueue() {
 	if Queue != nil {
-		Queue = nil
-		Queue = list.New()
+		Queue.mu.Lock()
+		defer Queue.mu.Unlock()
+		Queue.clear()
+		pending = make(map[string]*confirmation)
 	}
 }
 
@@ -70,6 +97,8 @@ func BootQueue(db *database.Database) error {
 	if err != nil {
 		return err
 	}
+	Queue.mu.Lock()
+	defer Queue.mu.Unlock()
 	var e *Event
 	Users, err = user.UsersList(db)
 	if err != nil {
@@ -77,7 +106,7 @@ func BootQueue(db *database.Database) error {
 	}
 	for _, rec := range records {
 		e = makeEventFromDBRecord(rec, db)
-		if e != nil {
+		if e != nil && e.catchUp(utils.Int64Interface(rec["next_at"])) {
 			e.pushToQueue()
 		}
 	}
@@ -99,6 +128,10 @@ func makeEventFromDBRecord(rec map[string]interface{}, db *database.Database) (e
 		Schedule:  utils.Int64Interface(rec["schedule"]),
 		Etext:     utils.StringInterface(rec["etext"]),
 		Etype:     utils.StringInterface(rec["etype"]),
+		EndsAt:    utils.Int64Interface(rec["ends_at"]),
+		Remaining: int(utils.Int64Interface(rec["remaining"])),
+		LastFired: utils.Int64Interface(rec["last_fired_at"]),
+		Snoozed:   utils.Int64Interface(rec["snoozed_until"]),
 	}
 	err := e.updateSchedule()
 	if err != nil {
@@ -129,35 +162,79 @@ func Create(payload string, db *database.Database, token string) (*Event, error)
 	if len(errs) > 0 {
 		return nil, fmt.Errorf(strings.Join(errs, ", "))
 	}
+	return ei.process()
+}
+
+// CreateFromCommand handles the text of a slash command, which comes with the
+// user and channel but no timestamp
+func CreateFromCommand(db *database.Database, token, uhash, channel, text string) (*Event, error) {
+	ei := &EventInit{
+		Database:   db,
+		slackToken: token,
+		Channel:    channel,
+		Timestamp:  float64(now()),
+		Etext:      text,
+		Etype:      "slash_command",
+		User:       uhash,
+	}
+	return ei.process()
+}
+
+func (ei *EventInit) process() (*Event, error) {
+	// looking the user up may call Slack, so it's done before taking the lock
+	err := ei.resolveUser()
+	if err != nil {
+		return nil, err
+	}
+	Queue.mu.Lock()
+	defer Queue.mu.Unlock()
 	return ei.schedule()
 }
 
 func (e *Event) MessageBody() string {
-	if len(e.Emessage) < 1 {
+	if len(e.Emessage) < 1 || e.intent == IntentHelp {
 		return e.noneMessage()
 	}
-	if strings.Contains(strings.ToLower(e.Emessage), "your todo list") {
+	if e.intent == IntentList {
 		return ""
 	}
 	return fmt.Sprintf("\n'%s'", e.echo())
 }
 
+// Message is the reply to the user's message: a complete one for the
+// commands that have their own (removing a reminder, listing them, asking a
+// question), or else a description of the reminder's schedule
 func (e *Event) Message() string {
 	if len(e.Emessage) < 1 {
 		return "Sorry, I couldn't understand what you're asking..."
 	}
-	if strings.Contains(strings.ToLower(e.Emessage), "stop reminding you") {
-		return e.Emessage
-	}
-	if strings.Contains(strings.ToLower(e.Emessage), "your todo list") {
+	if e.verbatim {
 		return e.Emessage
 	}
 	return fmt.Sprintf("I'll remind you %s", e.Emessage)
 }
 
 func (e *Event) Processed() map[string]string {
+	e.LastFired = now()
+	if e.Once {
+		return e.processedOnce()
+	}
+	if e.Snoozed > 0 {
+		return e.processedSnoozed()
+	}
 	e.Timestamp = float64(e.Next)
 	e.setNext()
+	if e.lastOccurrence() {
+		return e.processedLast()
+	}
+	err := e.save()
+	if err != nil {
+		fmt.Println("error saving event", err.Error())
+	}
+	return e.reminder()
+}
+
+func (e *Event) reminder() map[string]string {
 	return map[string]string{
 		"message": fmt.Sprintf("\n'%s'", e.echo()),
 		"heading": fmt.Sprintf("Hi %s, %s", e.UserTag(), "here's a friendly reminder"),
@@ -165,6 +242,8 @@ func (e *Event) Processed() map[string]string {
 }
 
 func CheckEventUsers(db *database.Database, token string) error {
+	Queue.mu.Lock()
+	defer Queue.mu.Unlock()
 	if Users == nil || len(Users) < 1 {
 		return nil
 	}
@@ -209,10 +288,12 @@ func (e *Event) noneMessage() string {
 
 func exampleMessage() string {
 	return fmt.Sprintf(
-		"Try asking me to schedule an event:\n'%s'\nOr, remove an existing one:\n'%s'\nType 'list' for your todo list\nYou can set daily reminders, for instance:\n%s",
+		"Try asking me to schedule an event:\n'%s'\nOr, remove an existing one:\n'%s'\nType 'list' for your todo list\nYou can set daily reminders, for instance:\n%s\nOr weekly ones:\n%s\nOr just once:\n%s",
 		"\"Remind me to call my lawyer every day\" or \"Remind me to log my time every two hours\"",
 		"\"Done calling my lawyer\" or \"Done logging my time\"",
 		"\"Remind me to pack my lunch every day at 8 am\"",
+		"\"Remind me to submit my timesheet every Friday at 4 pm\" or \"Remind me to go for a run on weekends\"",
+		"\"Remind me to check the oven in 20 minutes\" or \"Remind me to call the plumber tomorrow at 9 am\"",
 	)
 }
 
@@ -220,59 +301,105 @@ func (e *Event) UserTag() string {
 	return fmt.Sprintf("<@%s>", e.User.Hash())
 }
 
-func (ei *EventInit) schedule() (ev *Event, err error) {
-	ev, err = ei.list()
-	if err != nil {
-		return nil, err
+// Destination is where messages about the event are posted: the channel it
+// was created in, or else a direct message to the user
+func (e *Event) Destination() string {
+	if len(e.Channel) > 0 {
+		return e.Channel
 	}
-	if ev != nil {
+	return e.User.Hash()
+}
+
+// schedule does what the message asks, as ParseCommand understands it
+func (ei *EventInit) schedule() (*Event, error) {
+	c := ParseCommand(ei.echo())
+	switch c.Intent {
+	case IntentList:
+		return ei.list()
+	case IntentConfirm:
+		return ei.confirm(c.Answer)
+	case IntentSnooze:
+		return ei.snooze(c)
+	case IntentComplete:
+		return ei.complete(c)
+	case IntentUpdate:
+		return ei.change(c)
+	case IntentAdd:
+		return ei.add()
+	}
+	return ei.help(c.Intent), nil
+}
+
+// add creates a new reminder, unless it looks like one of the user's others
+// and they need to be asked which they meant
+func (ei *EventInit) add() (*Event, error) {
+	if ev := ei.askToUpdate(); ev != nil {
 		return ev, nil
 	}
-	e, err := ei.createOrUpdate()
+	e, err := ei.create()
 	if err != nil {
 		return nil, err
 	}
-	ev, err = ei.setSchedule(e)
-	return ev, err
+	return ei.setSchedule(e)
 }
 
-func (ei *EventInit) list() (*Event, error) {
-	if strings.ToLower(ei.echo()) != "list" {
-		return nil, nil
+// complete removes the reminder referred to or described
+func (ei *EventInit) complete(c Command) (*Event, error) {
+	if len(c.Reference) > 0 {
+		e, ref, _ := ei.referenced(c.Reference)
+		if e == nil {
+			return ei.notListed(ref), nil
+		}
+		return e.remove()
 	}
-	ei.Schedule = 0
-	e, err := ei.create()
-	if err != nil {
-		return nil, err
+	candidates := matching(ei.User, normalize(c.Text), (*Event).echo)
+	if len(candidates) > 1 {
+		return ei.ambiguous(candidates, "done %d"), nil
+	}
+	if len(candidates) < 1 {
+		return ei.sorry("Sorry, I couldn't find that on your todo list"), nil
 	}
-	return e.eventList()
+	return candidates[0].remove()
 }
 
-func (e *Event) eventList() (*Event, error) {
-	eventList := getEventList(e.User.Hash())
-	if len(eventList) < 1 {
-		e.Emessage = "Your todo list is empty!"
+// change updates the schedule of the reminder referred to ("change 2 to every
+// day at 9am"), or the one that best matches the request ("rather remind me
+// to eat every three hours"), which is otherwise added as a new reminder
+func (ei *EventInit) change(c Command) (*Event, error) {
+	if len(c.Reference) > 0 {
+		e, ref, _ := ei.referenced(c.Reference)
+		if e == nil {
+			return ei.notListed(ref), nil
+		}
+		if len(c.Text) < 1 {
+			return ei.sorry(fmt.Sprintf("Sorry, what should I change %s to? Try `update %s every day at 9am`", ref, c.Reference)), nil
+		}
+		ei.Etext = fmt.Sprintf("remind me to %s %s", e.task(), c.Text)
+		return ei.handleExisting(e)
+	}
+	candidates := matching(ei.User, normalize(c.Text), (*Event).echo)
+	if len(candidates) > 1 {
+		return ei.ambiguous(candidates, "update %d every day at 9am"), nil
 	}
-	message := make([]string, 0)
-	for _, v := range eventList {
-		if v.Schedule > 0 {
-			message = append(message, v.Etext)
+	if len(candidates) < 1 {
+		e, err := ei.create()
+		if err != nil {
+			return nil, err
 		}
+		return ei.setSchedule(e)
 	}
-	e.Emessage = fmt.Sprintf("Your todo list:\n%s", strings.Join(message, "\n"))
-	return e, nil
+	return ei.handleExisting(candidates[0])
 }
 
-func getEventList(userHash string) []*Event {
-	result := make([]*Event, 0)
-	for i := Queue.Front(); i != nil; i = i.Next() {
-		if k, ok := i.Value.(*Event); ok {
-			if k.User.Hash() == userHash {
-				result = append(result, k)
-			}
-		}
+// help explains what the bot can do, when it's asked to or when it can't tell
+// what the message means
+func (ei *EventInit) help(intent Intent) *Event {
+	e := ei.sorry("")
+	if intent == IntentHelp {
+		e.Emessage = "Here's what I can do for you:"
 	}
-	return result
+	e.intent = intent
+	return e
 }
 
 func (e *Event) insert() (*Event, error) {
@@ -296,42 +423,27 @@ func (e *Event) update(ei *EventInit) (*Event, error) {
 	e.Schedule = ei.Schedule
 	e.Etext = ei.Etext
 	e.Etype = ei.Etype
-	e.text = sanitize(e.echo())
-	_, err := e.Exec(updateEvent, []interface{}{
-		e.Channel, e.Timestamp, e.Schedule, e.Etext, e.Etype, e.ID,
-	})
-	return e, err
-}
-
-func (ei *EventInit) createOrUpdate() (e interface{}, err error) {
-	if Queue.Len() < 1 {
-		return ei.create()
-	}
-	if e = ei.exists(); e != nil {
-		return e, nil
-	}
-	return ei.create()
+	e.Snoozed = 0
+	e.text = nil
+	return e, e.save()
 }
 
-func (ei *EventInit) exists() interface{} {
-	for i := Queue.Front(); i != nil; i = i.Next() {
-		if k, ok := i.Value.(*Event); ok {
-			if ei.matches(k) {
-				return i
-			}
+// matching returns the user's listed events that best match the normalized
+// words, if they match at least half of them; text is what's matched on each
+// event, all of what it says with Event.echo or just its task with Event.task
+func matching(uhash string, words []string, text func(*Event) string) []*Event {
+	best := 0.5
+	result := make([]*Event, 0)
+	for _, k := range listed(uhash) {
+		matchQuotient := getMatchQuotient(words, normalize(text(k)))
+		if matchQuotient > best {
+			best = matchQuotient
+			result = []*Event{k}
+		} else if matchQuotient == best {
+			result = append(result, k)
 		}
 	}
-	return nil
-}
-
-func (ei *EventInit) matches(e *Event) bool {
-	if ei.User != e.User.Hash() {
-		return false
-	}
-	eiText := sanitize(ei.echo())
-	eText := sanitize(e.echo())
-	matchQuotient := getMatchQuotient(eiText, eText)
-	return matchQuotient >= 0.5
+	return result
 }
 
 func excludeWord(word string) bool {
@@ -341,14 +453,20 @@ func excludeWord(word string) bool {
 		word == "me" ||
 		word == "to" ||
 		word == "i" ||
+		strings.Contains(word, "minute") ||
 		strings.Contains(word, "hour") ||
-		strings.Contains(word, "day")
+		strings.Contains(word, "day") ||
+		strings.Contains(word, "week") ||
+		strings.Contains(word, "month") ||
+		strings.Contains(word, "year")
 }
 
+// removeWords leaves out the words that ask for a reminder to be removed,
+// which say nothing about which reminder it is
 func removeWords(list []string) []string {
 	result := make([]string, 0)
 	for _, word := range list {
-		if excludeWord(word) == true {
+		if cancelStems[word] {
 			continue
 		}
 		result = append(result, word)
@@ -401,17 +519,21 @@ func (ei *EventInit) create() (*Event, error) {
 		Etext:     ei.Etext,
 		Etype:     ei.Etype,
 	}
-	u, err := ei.lookupUser()
-	if err != nil {
-		return nil, err
-	}
-	if Users[u.ID()] == nil {
+	u := ei.user
+	if known := Users[u.ID()]; known != nil {
+		u = known
+	} else {
 		Users[u.ID()] = u
 	}
 	e.User = u
 	return e.insert()
 }
 
+func (ei *EventInit) resolveUser() (err error) {
+	ei.user, err = ei.lookupUser()
+	return err
+}
+
 func (ei *EventInit) lookupUser() (*user.User, error) {
 	result, err := ei.QueryRaw(findUser, []interface{}{ei.User})
 	if err != nil {
@@ -471,67 +593,57 @@ func sanitize(etext string) []string {
 	return strings.Split(etext, " ")
 }
 
-func (ei *EventInit) setSchedule(input interface{}) (*Event, error) {
-	if input == nil {
+func (ei *EventInit) setSchedule(e *Event) (*Event, error) {
+	if e == nil {
 		return nil, nil
 	}
-	switch e := input.(type) {
-	case *list.Element:
-		return ei.handleExisting(e)
-	case *Event:
-		e.updateSchedule()
-		e.pushToQueue()
-		return e, nil
-	default:
-		return nil, fmt.Errorf("type unrecognised")
-	}
+	e.updateSchedule()
+	e.setBounds()
+	e.pushToQueue()
+	return e, nil
 }
 
 func (e *Event) pushToQueue() {
-	if e.Schedule < 1 {
+	if e.Schedule < 1 && !e.Once {
 		// Don't push the event if it's not parseable
 		e.Next = 0
+		Queue.remove(e)
 		return
 	}
-	Queue.PushBack(e)
+	Queue.push(e)
 }
 
 func (e *Event) save() error {
 	_, err := e.Exec(updateEvent, []interface{}{
-		e.Channel, e.Timestamp, e.Schedule, e.Etext, e.Etype, e.ID,
+		e.Channel, e.Timestamp, e.Schedule, e.Cron, e.Once, e.EndsAt, e.Remaining, e.Next, e.LastFired, e.Snoozed, e.Etext, e.Etype, e.ID,
 	})
 	return err
 }
 
-func (ei *EventInit) handleExisting(input *list.Element) (e *Event, err error) {
-	var ok bool
-	if e, ok = input.Value.(*Event); ok {
-		e, err = e.update(ei)
-		if err != nil {
-			return nil, err
-		}
-		if e.isScheduleRemoval() == true {
-			return e.remove(input)
-		}
-		e.updateSchedule()
-		return e, nil
+func (ei *EventInit) handleExisting(e *Event) (*Event, error) {
+	e, err := e.update(ei)
+	if err != nil {
+		return nil, err
 	}
-	return nil, fmt.Errorf("could not find existing event in list")
+	e.updateSchedule()
+	e.setBounds()
+	e.pushToQueue()
+	return e, nil
 }
 
-func (e *Event) remove(input *list.Element) (*Event, error) {
+func (e *Event) remove() (*Event, error) {
 	e.Emessage = "I'll stop reminding you"
+	e.verbatim = true
 	e.Schedule = 0
+	e.Once = false
 	e.Next = 0
-	Queue.Remove(input)
+	e.Snoozed = 0
+	Queue.remove(e)
 	return e, e.save()
 }
 
 func (e *Event) isScheduleAdd() bool {
-	if e.text == nil {
-		e.text = sanitize(e.echo())
-	}
-	for _, add := range e.text {
+	for _, add := range e.words() {
 		if reminders[add] {
 			return true
 		}
@@ -539,29 +651,35 @@ func (e *Event) isScheduleAdd() bool {
 	return false
 }
 
-func (e *Event) isScheduleRemoval() bool {
-	if e.text == nil {
-		e.text = sanitize(e.echo())
-	}
-	for _, remove := range e.text {
-		if cancellations[remove] {
-			return true
-		}
-	}
-	return false
+func (e *Event) updateSchedule() (err error) {
+	e.parseSchedule()
+	return e.save()
 }
 
-func (e *Event) updateSchedule() (err error) {
+// parseSchedule works out the schedule from the event's text and when it's
+// next due
+func (e *Event) parseSchedule() {
+	e.Once = false
+	e.verbatim = false
+	e.months = 0
+	e.month = 0
+	e.monthDay = 0
 	if !e.isScheduleAdd() {
 		e.Schedule = 0
+	} else if e.parseCron() {
+		// the cron expression decides the schedule
+	} else if e.parseWeekdays() {
+		e.scheduleWeekdays()
 	} else if strings.Contains(strings.ToLower(e.Etext), "every") {
 		e.every()
+	} else if e.parseOnce() {
+		e.Schedule = 0
 	} else {
 		e.Schedule = int64(day)
 		e.Emessage = "every day"
 	}
 	e.setNext()
-	return e.save()
+	e.summary = e.Emessage
 }
 
 func now() int64 {
@@ -571,37 +689,105 @@ func now() int64 {
 	return time.Now().Unix()
 }
 
+// now is the current time, or the time a preview is worked out as of
+func (e *Event) now() int64 {
+	if e.asOf > 0 {
+		return e.asOf
+	}
+	return now()
+}
+
 func (e *Event) setNext() {
 	if e.Schedule < 1 {
 		return
 	}
 	if e.nextSet {
 		e.nextSet = false
+		if e.Next > e.now() {
+			return
+		}
+		// an event loaded on boot may be anchored to a time long past
+		e.Timestamp = float64(e.Next)
+	}
+	if e.missed > 0 {
+		// still catching up on occurrences missed while the bot was down
+		e.missed--
+		if next := e.following(int64(e.Timestamp)); next <= e.now() {
+			e.Next = next
+			return
+		}
+		e.missed = 0
+	}
+	if e.cron != nil {
+		// cron schedules are anchored to the wall clock, so there's no need to
+		// step through the occurrences that have already passed
+		from := int64(e.Timestamp)
+		if from < e.now() {
+			from = e.now()
+		}
+		e.Next = e.following(from)
 		return
 	}
-	next := int64(e.Timestamp + float64(e.Schedule))
+	next := e.following(int64(e.Timestamp))
 	for {
-		if next <= now() {
+		if next <= e.now() {
 			e.Timestamp = float64(next)
-			next = int64(e.Timestamp + float64(e.Schedule))
+			next = e.following(next)
 			continue
 		}
 		break
 	}
-	e.Next = int64(e.Timestamp + float64(e.Schedule))
+	e.Next = next
+}
+
+// following returns the first occurrence of the event after ts
+func (e *Event) following(ts int64) int64 {
+	if e.cron != nil {
+		next := e.cron.next(time.Unix(ts, 0).In(e.location()))
+		if next.IsZero() {
+			return 0
+		}
+		return next.Unix()
+	}
+	if e.months > 0 {
+		return e.addMonths(ts)
+	}
+	if e.Schedule%int64(day) == 0 {
+		// whole days are counted on the calendar, so that the time of day
+		// holds across daylight saving changes
+		days := int(e.Schedule / int64(day))
+		return e.alignWeekday(time.Unix(ts, 0).In(e.location()).AddDate(0, 0, days).Unix())
+	}
+	return e.alignWeekday(ts + e.Schedule)
 }
 
 func (e *Event) every() (err error) {
-	number := e.getNumber()
-	if strings.Contains(strings.ToLower(e.Etext), "day") {
-		e.scheduleDay(number)
-		return nil
+	number, unit := e.everyInterval()
+	if len(unit) < 1 {
+		number = e.getNumber()
+		if strings.Contains(strings.ToLower(e.Etext), "day") {
+			unit = "day"
+		} else if strings.Contains(strings.ToLower(e.Etext), "hour") {
+			unit = "hour"
+		}
 	}
-	if strings.Contains(strings.ToLower(e.Etext), "hour") {
+	switch unit {
+	case "minute":
+		e.scheduleMinute(number)
+	case "hour":
 		e.scheduleHour(number)
-		return nil
+	case "day":
+		e.scheduleDay(number)
+	case "week":
+		e.scheduleWeek(number)
+	case "month":
+		e.scheduleMonth(number)
+	case "year":
+		e.scheduleYear(number)
+	default:
+		return fmt.Errorf("nothing is parsed")
 	}
-	return fmt.Errorf("nothing is parsed")
+	return nil
 }
 
 func (e *Event) scheduleDay(number int) {
@@ -616,26 +802,32 @@ func (e *Event) scheduleDay(number int) {
 }
 
 func (e *Event) hasSpecificTime() {
-	if e.text == nil {
-		e.text = sanitize(e.echo())
+	if t := e.timeOfDay(); len(t) > 0 {
+		e.setSpecificTime(t)
 	}
+}
+
+// timeOfDay returns the time asked for after "at", if any, formatted as
+// 15:04:05
+func (e *Event) timeOfDay() string {
 	parseTime := false
 	find := make([]string, 0)
-	for _, v := range e.text {
+	for _, v := range e.words() {
 		if v == "at" {
 			parseTime = true
 			continue
 		}
 		if parseTime {
-			find = append(find, v)
+			find = append(find, splitMeridiem(v)...)
 		}
 	}
-	if parseTime && len(find) > 0 {
-		e.specifyTime(find)
+	if !parseTime || len(find) < 1 {
+		return ""
 	}
+	return e.specifyTime(find)
 }
 
-func (e *Event) specifyTime(find []string) {
+func (e *Event) specifyTime(find []string) string {
 	t := e.amOrPmTime(find)
 	if len(t) < 1 {
 		for _, v := range find {
@@ -645,29 +837,27 @@ func (e *Event) specifyTime(find []string) {
 			}
 		}
 	}
-	if len(t) > 0 {
-		e.setSpecificTime(t)
-	}
+	return t
 }
 
 func (e *Event) setSpecificTime(t string) {
 	e.setNext()
-	nextT := time.Unix(int64(e.Timestamp), 0)
-	nextTAdj, err := time.Parse(tFormat, fmt.Sprintf("%s %s", nextT.Format("2006-01-02"), t))
-	Adj := nextTAdj.Add(time.Second * time.Duration(-1*e.User.TZOffset()))
+	loc := e.location()
+	nextT := time.Unix(int64(e.Timestamp), 0).In(loc)
+	nextTAdj, err := time.ParseInLocation(tFormat, fmt.Sprintf("%s %s", nextT.Format("2006-01-02"), t), loc)
 	if err != nil {
 		fmt.Println("error parsing time", err.Error())
 		return
 	}
-	e.Timestamp = float64(Adj.Unix())
-	if nextTAdj.Unix() < nextT.Unix() {
-		e.Next = int64(e.Timestamp) + int64(day)
+	e.Timestamp = float64(nextTAdj.Unix())
+	if nextTAdj.Before(nextT) {
+		e.Next = nextTAdj.AddDate(0, 0, 1).Unix()
 	} else {
-		e.Next = int64(e.Timestamp)
+		e.Next = nextTAdj.Unix()
 	}
+	e.Next = e.alignWeekday(e.Next)
 	e.nextSet = true
 	e.Emessage = fmt.Sprintf("%s at %s", e.Emessage, nextTAdj.Format("3:04 pm"))
-	e.save()
 }
 
 func (e *Event) amOrPmTime(find []string) string {
@@ -745,10 +935,7 @@ func (e *Event) scheduleHour(number int) {
 }
 
 func (e *Event) getNumber() int {
-	if e.text == nil {
-		e.text = sanitize(e.echo())
-	}
-	for _, v := range e.text {
+	for _, v := range e.words() {
 		if v == "at" {
 			break
 		}
@@ -762,6 +949,14 @@ func (e *Event) getNumber() int {
 	return 0
 }
 
+// words are the sanitized words of the event, less any end conditions
+func (e *Event) words() []string {
+	if e.text == nil {
+		_, _, e.text = e.bounds(sanitize(e.echo()))
+	}
+	return e.text
+}
+
 func (e *Event) echo() string {
 	return strings.Trim(removeTag.ReplaceAllString(e.Etext, ""), " ")
 }
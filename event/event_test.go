@@ -8,6 +8,7 @@ import (
 	"time"
 
 	jsonextract "github.com/blainemoser/JsonExtract"
+	utils "github.com/blainemoser/goutils"
 	"github.com/blainemoser/todobot/tests"
 	"github.com/blainemoser/todobot/testsuite"
 	"github.com/blainemoser/todobot/user"
@@ -148,6 +149,26 @@ func TestList(t *testing.T) {
 	if !strings.Contains(strings.ToLower(e.Emessage), "your todo list") {
 		t.Fatalf("expected list event message to contain a todo list, got %s", e.Emessage)
 	}
+	items := listed(suite.TestEnv["testUser"])
+	if len(items) != 2 {
+		t.Fatalf("expected two items on the todo list, got %d", len(items))
+	}
+	for _, expects := range []string{
+		fmt.Sprintf("1. pick up the laundry (#%d): every 2 hour(s), next on Sunday 30 January at 5:25 am", items[0].ID),
+		fmt.Sprintf("2. do tax forms (#%d): every 2 hour(s), next on Sunday 30 January at 5:25 am", items[1].ID),
+	} {
+		if !strings.Contains(e.Emessage, expects) {
+			t.Errorf("expected the todo list to contain '%s', got %s", expects, e.Emessage)
+		}
+	}
+	ClearQueue()
+	e, err = Create(testList(), suite.TestDatabase, suite.TestEnv["slackToken"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Emessage != "Your todo list is empty!" {
+		t.Errorf("expected an empty todo list, got %s", e.Emessage)
+	}
 }
 
 func TestSpecificTime(t *testing.T) {
@@ -179,6 +200,563 @@ func TestSpecificTime(t *testing.T) {
 	checkTime(t, e.Next, "2022-01-30 02:34")
 }
 
+func TestWeekdays(t *testing.T) {
+	ClearQueue()
+	for _, tc := range []struct {
+		message string
+		next    string
+		rule    string
+	}{
+		{"Remind me to submit my timesheet every Friday at 4pm", "2022-02-04 14:00", "every Friday at 4:00 pm"},
+		{"Remind me to water the plants every Monday and Thursday at 8 am", "2022-01-31 06:00", "every Monday and Thursday at 8:00 am"},
+		{"Remind me to stretch every weekday at 9 am", "2022-01-31 07:00", "every weekday at 9:00 am"},
+		{"Remind me to go hiking on weekends at 7 am", "2022-01-30 05:00", "on weekends at 7:00 am"},
+		{"Remind me to buy groceries every sat at 8 am", "2022-02-05 06:00", "every Saturday at 8:00 am"},
+		{"Remind me to call gran every sun at 9 am", "2022-01-30 07:00", "every Sunday at 9:00 am"},
+		{"Remind me to sit in the sun every day at 10 am", "2022-01-30 08:00", "every day at 10:00 am"},
+	} {
+		e, err := Create(testMessage(tc.message), suite.TestDatabase, suite.TestEnv["slackToken"])
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkTime(t, e.Next, tc.next)
+		if !strings.Contains(e.Message(), tc.rule) {
+			t.Errorf("expected message to contain '%s', got '%s'", tc.rule, e.Message())
+		}
+	}
+}
+
+func TestWeekdaysProcessed(t *testing.T) {
+	ClearQueue()
+	e, err := Create(testMessage("Remind me to go hiking on weekends at 7 am"), suite.TestDatabase, suite.TestEnv["slackToken"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.Processed()
+	checkTime(t, e.Next, "2022-02-05 05:00")
+	e.Processed()
+	checkTime(t, e.Next, "2022-02-06 05:00")
+}
+
+func TestOnce(t *testing.T) {
+	ClearQueue()
+	for _, tc := range []struct {
+		message string
+		next    string
+		rule    string
+	}{
+		{"Remind me to check the oven in 20 minutes", "2022-01-30 01:45", "in 20 minute(s)"},
+		{"Remind me to call the plumber tomorrow at 9am", "2022-01-31 07:00", "on Monday 31 January at 9:00 am"},
+		{"Remind me to renew my licence on 3 March at 14h00", "2022-03-03 12:00", "on Thursday 3 March at 2:00 pm"},
+		{"Remind me to book flights next Tuesday at 10 am", "2022-02-01 08:00", "on Tuesday 1 February at 10:00 am"},
+		{"Remind me to pay the rent today", "2022-01-30 07:00", "on Sunday 30 January at 9:00 am"},
+	} {
+		e, err := Create(testMessage(tc.message), suite.TestDatabase, suite.TestEnv["slackToken"])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !e.Once {
+			t.Errorf("expected '%s' to be a one-off reminder", tc.message)
+		}
+		checkTime(t, e.Next-(e.Next%60), tc.next)
+		if !strings.Contains(e.Message(), tc.rule) {
+			t.Errorf("expected message to contain '%s', got '%s'", tc.rule, e.Message())
+		}
+	}
+}
+
+func TestOncePassed(t *testing.T) {
+	ClearQueue()
+	for _, tc := range []struct {
+		message string
+		reply   string
+	}{
+		{"Remind me to call the bank today at 2am", "Sorry, Sunday 30 January at 2:00 am has already passed"},
+		{"Remind me to post the letter on 2022-01-29", "Sorry, Saturday 29 January at 9:00 am has already passed"},
+		{"Remind me to lock the door tonight", "on Sunday 30 January at 8:00 pm"},
+	} {
+		e, err := Create(testMessage(tc.message), suite.TestDatabase, suite.TestEnv["slackToken"])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(e.Message(), tc.reply) {
+			t.Errorf("expected the reply to '%s' to contain '%s', got '%s'", tc.message, tc.reply, e.Message())
+		}
+		if e.Once && e.Next <= testingNow {
+			t.Errorf("expected '%s' not to be due before it was asked for", tc.message)
+		}
+	}
+	if Queue.Len() != 1 {
+		t.Errorf("expected only the reminder still to come to be queued, got %d", Queue.Len())
+	}
+}
+
+func TestOnceProcessed(t *testing.T) {
+	ClearQueue()
+	e, err := Create(testMessage("Remind me to check the oven in 20 minutes"), suite.TestDatabase, suite.TestEnv["slackToken"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := make(chan []map[string]string, 1)
+	go ProcessQueue(c)
+	result := <-c
+	if len(result) != 1 {
+		t.Fatalf("expected one event to be processed, got %d", len(result))
+	}
+	if e.Next != 0 {
+		t.Errorf("expected next on a processed one-off event to be 0, got %d", e.Next)
+	}
+	err = checkEventNotInQueue(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestIntervals(t *testing.T) {
+	ClearQueue()
+	for _, tc := range []struct {
+		message string
+		next    string
+		rule    string
+	}{
+		{"Remind me to stand up every 30 minutes", "2022-01-30 01:55", "every 30 minute(s)"},
+		{"Remind me to water the garden every 2 weeks", "2022-02-13 01:25", "every 2 week(s)"},
+		{"Remind me to pay rent on the 1st of every month at 9 am", "2022-02-01 07:00", "every month on the 1st at 9:00 am"},
+		{"Remind me to renew the domain every year", "2023-01-30 01:25", "every year on 30 January"},
+		{"Remind me to file taxes every year on 15 April", "2022-04-15 01:25", "every year on 15 April"},
+		{"Remind me to service the boiler every year on the 15th of January at 9 am", "2023-01-15 07:00", "every year on 15 January at 9:00 am"},
+	} {
+		e, err := Create(testMessage(tc.message), suite.TestDatabase, suite.TestEnv["slackToken"])
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkTime(t, e.Next-(e.Next%60), tc.next)
+		if !strings.Contains(e.Message(), tc.rule) {
+			t.Errorf("expected message to contain '%s', got '%s'", tc.rule, e.Message())
+		}
+	}
+}
+
+func TestMonthLengths(t *testing.T) {
+	ClearQueue()
+	e, err := Create(testMessage("Remind me to back up my photos on the 31st of every month at 8 am"), suite.TestDatabase, suite.TestEnv["slackToken"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkTime(t, e.Next, "2022-01-31 06:00")
+	e.Processed()
+	checkTime(t, e.Next, "2022-02-28 06:00")
+	e.Processed()
+	checkTime(t, e.Next, "2022-03-31 06:00")
+}
+
+func TestCron(t *testing.T) {
+	ClearQueue()
+	e, err := Create(testMessage("Remind me to rotate keys cron “0 9 * * 1-5”"), suite.TestDatabase, suite.TestEnv["slackToken"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Cron != "0 9 * * 1-5" || e.task() != "rotate keys" {
+		t.Errorf("expected cron expression to be '%s' for 'rotate keys', got '%s' for '%s'", "0 9 * * 1-5", e.Cron, e.task())
+	}
+	checkTime(t, e.Next, "2022-01-31 07:00")
+	e.Processed()
+	checkTime(t, e.Next, "2022-02-01 07:00")
+	l, err := Create(testList(), suite.TestDatabase, suite.TestEnv["slackToken"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(l.Emessage, "on the cron schedule \"0 9 * * 1-5\"") {
+		t.Errorf("expected list to show the cron expression, got %s", l.Emessage)
+	}
+	e, err = Create(testMessage("Remind me to fix the cron job every day"), suite.TestDatabase, suite.TestEnv["slackToken"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(e.Cron) > 0 || e.Schedule != int64(day) || e.task() != "fix the cron job" {
+		t.Errorf("expected the word cron not to be taken for a cron schedule, got '%s' every %d seconds for '%s'", e.Cron, e.Schedule, e.task())
+	}
+	e, err = Create(testMessage("Remind me to back up the database cron “0 25 * * *”"), suite.TestDatabase, suite.TestEnv["slackToken"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Schedule > 0 || !strings.Contains(e.Message(), "Sorry, I couldn't read that cron schedule") {
+		t.Errorf("expected an invalid cron schedule to be explained, got '%s'", e.Message())
+	}
+}
+
+func TestCronExpressions(t *testing.T) {
+	from := time.Date(2022, time.January, 30, 10, 7, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		expr    string
+		expects string
+	}{
+		{"*/15 * * * *", "2022-01-30 10:15"},
+		{"0 0 1 * *", "2022-02-01 00:00"},
+		{"30 8 * * mon,wed", "2022-01-31 08:30"},
+		{"0 12 13 * 5", "2022-02-04 12:00"},
+		{"@daily", "2022-01-31 00:00"},
+		{"0 9 29 2 *", "2024-02-29 09:00"},
+	} {
+		c, err := parseCron(tc.expr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		next := c.next(from).Format("2006-01-02 15:04")
+		if next != tc.expects {
+			t.Errorf("expected '%s' to next run at %s, got %s", tc.expr, tc.expects, next)
+		}
+	}
+	for _, expr := range []string{"61 * * * *", "0 9 * *", "0 9 * * 1-x", "5-1 * * * *"} {
+		if _, err := parseCron(expr); err == nil {
+			t.Errorf("expected '%s' to be an invalid cron expression", expr)
+		}
+	}
+}
+
+func TestBounds(t *testing.T) {
+	ClearQueue()
+	for _, tc := range []struct {
+		message     string
+		occurrences int
+		rule        string
+	}{
+		{"Remind me to take my pills every day at 8 am 3 times", 3, "every day at 8:00 am, 3 more time(s)"},
+		{"Remind me to water the seedlings every day until Friday", 5, "every day until Friday 4 February"},
+		{"Remind me to sweep the porch until Friday every day at 9am", 6, "every day at 9:00 am until Friday 4 February"},
+		{"Remind me to feed the neighbour's cat every day for 2 days", 2, "every day until Tuesday 1 February"},
+		{"For the next 2 days, remind me to water the lawn every day", 2, "every day until Tuesday 1 February"},
+	} {
+		e, err := Create(testMessage(tc.message), suite.TestDatabase, suite.TestEnv["slackToken"])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(e.Message(), tc.rule) {
+			t.Errorf("expected message to contain '%s', got '%s'", tc.rule, e.Message())
+		}
+		var result map[string]string
+		occurrences := 0
+		for e.Next > 0 && occurrences <= tc.occurrences {
+			result = e.Processed()
+			occurrences++
+		}
+		if occurrences != tc.occurrences {
+			t.Errorf("expected '%s' to be processed %d times, got %d", tc.message, tc.occurrences, occurrences)
+		}
+		if !strings.Contains(result["message"], "last reminder") {
+			t.Errorf("expected the final message to say that it was the last reminder, got '%s'", result["message"])
+		}
+	}
+}
+
+func TestUnbounded(t *testing.T) {
+	ClearQueue()
+	for _, tc := range []struct {
+		message  string
+		schedule int64
+	}{
+		{"Remind me to stretch for 5 minutes every hour", int64(hour)},
+		{"Remind me to take my pills 3 times a day", int64(day)},
+	} {
+		e, err := Create(testMessage(tc.message), suite.TestDatabase, suite.TestEnv["slackToken"])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if e.EndsAt > 0 || e.Remaining > 0 || e.Schedule != tc.schedule {
+			t.Errorf(
+				"expected '%s' to run every %d seconds without end, got every %d seconds until %d, %d more times",
+				tc.message, tc.schedule, e.Schedule, e.EndsAt, e.Remaining,
+			)
+		}
+	}
+}
+
+func TestDaylightSaving(t *testing.T) {
+	ClearQueue()
+	defer func(n int64) { testingNow = n }(testingNow)
+	for _, tc := range []struct {
+		tz      string
+		offset  int64
+		now     string
+		message string
+		next    []string
+	}{
+		// British Summer Time starts at 01:00 UTC on 27 March 2022
+		{"Europe/London", 0, "2022-03-26 10:00", "Remind me to have breakfast every day at 8 am", []string{
+			"2022-03-27 07:00", "2022-03-28 07:00",
+		}},
+		// Eastern Daylight Time ends at 06:00 UTC on 6 November 2022
+		{"America/New_York", -14400, "2022-11-05 10:00", "Remind me to walk the dog every day at 7 am", []string{
+			"2022-11-05 11:00", "2022-11-06 12:00", "2022-11-07 12:00",
+		}},
+		{"America/New_York", -14400, "2022-11-04 10:00", "Remind me to pay the nanny every Saturday and Monday at 5 pm", []string{
+			"2022-11-05 21:00", "2022-11-07 22:00",
+		}},
+	} {
+		testingNow = parseTestTime(t, tc.now).Unix()
+		e, err := testEventInZone(tc.tz, tc.offset, tc.message)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, next := range tc.next {
+			if i > 0 {
+				e.Processed()
+			}
+			checkTime(t, e.Next, next)
+		}
+	}
+}
+
+func TestCatchUp(t *testing.T) {
+	ClearQueue()
+	defer SetCatchUp(CatchUpOnce)
+	e, err := Create(testMessage("Remind me to stretch my legs every hour"), suite.TestDatabase, suite.TestEnv["slackToken"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the bot went down five hours ago, just after the event was last fired
+	down := testingNow - int64(5*hour)
+	for _, tc := range []struct {
+		policy string
+		fires  int
+	}{
+		{CatchUpSkip, 0},
+		{CatchUpOnce, 1},
+		{CatchUpAll, 5},
+	} {
+		err = SetCatchUp(tc.policy)
+		if err != nil {
+			t.Fatal(err)
+		}
+		booted := makeEventFromDBRecord(map[string]interface{}{
+			"id":       e.ID,
+			"user_id":  e.User.ID(),
+			"channel":  e.Channel,
+			"ts":       float64(down),
+			"schedule": e.Schedule,
+			"etext":    e.Etext,
+			"etype":    e.Etype,
+		}, suite.TestDatabase)
+		if booted == nil || !booted.catchUp(down+int64(hour)) {
+			t.Fatalf("expected the event to be queued on boot with the '%s' policy", tc.policy)
+		}
+		fires := 0
+		for booted.Next <= now() && fires <= maxCatchUp {
+			booted.Processed()
+			fires++
+		}
+		if fires != tc.fires {
+			t.Errorf("expected %d missed occurrence(s) to fire with the '%s' policy, got %d", tc.fires, tc.policy, fires)
+		}
+		if booted.Next != testingNow+int64(hour) {
+			t.Errorf("expected the event to be next due an hour from now with the '%s' policy", tc.policy)
+		}
+	}
+	booted := makeEventFromDBRecord(map[string]interface{}{
+		"id":       e.ID,
+		"user_id":  e.User.ID(),
+		"channel":  e.Channel,
+		"ts":       e.Timestamp,
+		"schedule": e.Schedule,
+		"etext":    e.Etext,
+		"etype":    e.Etype,
+	}, suite.TestDatabase)
+	if booted == nil || !booted.catchUp(testingNow+1234) || booted.Next != testingNow+1234 {
+		t.Errorf("expected an event's stored next time to be kept on boot when it's still to come")
+	}
+	if SetCatchUp("sometimes") == nil {
+		t.Errorf("expected an unknown catch-up policy to be rejected")
+	}
+}
+
+func TestBootNextAt(t *testing.T) {
+	ClearQueue()
+	e, err := Create(testMessage("Remind me to check the mailbox every hour"), suite.TestDatabase, suite.TestEnv["slackToken"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a time that can't be worked out from the timestamp and schedule
+	nextAt := testingNow + 1234
+	_, err = suite.TestDatabase.Exec("update events set next_at = ? where id = ?", []interface{}{nextAt, e.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ClearQueue()
+	err = BootQueue(suite.TestDatabase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ClearQueue()
+	booted := Queue.find(e.User.Hash(), e.ID)
+	if booted == nil {
+		t.Fatalf("expected the event to be queued on boot")
+	}
+	if booted.Next != nextAt {
+		t.Errorf("expected the event to be next due at its stored time %d, got %d", nextAt, booted.Next)
+	}
+}
+
+func TestActions(t *testing.T) {
+	ClearQueue()
+	e, err := Create(testMessage("Remind me to water the plants every day at 9 am"), suite.TestDatabase, suite.TestEnv["slackToken"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	uhash := e.User.Hash()
+	regular := e.Next
+	snoozed, err := Snooze(suite.TestDatabase, uhash, e.ID, SnoozeFor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snoozed != e || e.Next != testingNow+SnoozeFor {
+		t.Fatalf("expected the event to be snoozed for %d seconds", SnoozeFor)
+	}
+	e.Processed()
+	if e.Next != regular {
+		t.Errorf("expected the event to carry on with its schedule after the snooze")
+	}
+	_, err = Skip(suite.TestDatabase, uhash, e.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkTime(t, e.Next, "2022-01-31 07:00")
+	if !strings.Contains(e.Message(), "skipping the one before") {
+		t.Errorf("unexpected message after skipping '%s'", e.Message())
+	}
+	other, err := Complete(suite.TestDatabase, "U0SOMEONEELSE", e.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other != nil {
+		t.Errorf("expected another user not to be able to complete the event")
+	}
+	_, err = Complete(suite.TestDatabase, uhash, e.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if Queue.Contains(e) || e.Schedule != 0 {
+		t.Errorf("expected the event to be completed")
+	}
+}
+
+func TestSnooze(t *testing.T) {
+	ClearQueue()
+	e, err := Create(testMessage("Remind me to fold the laundry every day at 6 pm"), suite.TestDatabase, suite.TestEnv["slackToken"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkTime(t, e.Next, "2022-01-30 16:00")
+	snoozed, err := Create(testMessage("snooze the laundry for 30 minutes"), suite.TestDatabase, suite.TestEnv["slackToken"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snoozed != e || e.Next != testingNow+1800 || e.Snoozed != e.Next {
+		t.Fatalf("expected the laundry to be snoozed for 30 minutes")
+	}
+	if e.Message() != "I'll remind you again in 30 minute(s)" {
+		t.Errorf("unexpected message after snoozing '%s'", e.Message())
+	}
+	// a snooze survives a restart
+	booted := makeEventFromDBRecord(map[string]interface{}{
+		"id":            e.ID,
+		"user_id":       e.User.ID(),
+		"ts":            float64(testingNow),
+		"etext":         e.Etext,
+		"etype":         e.Etype,
+		"snoozed_until": e.Snoozed,
+	}, suite.TestDatabase)
+	if booted == nil || !booted.catchUp(e.Snoozed) || booted.Next != e.Snoozed {
+		t.Errorf("expected the snooze to be restored on boot")
+	}
+	_, err = Create(testMessage("snooze laundry until 5pm"), suite.TestDatabase, suite.TestEnv["slackToken"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkTime(t, e.Next, "2022-01-30 15:00")
+	e.Processed()
+	checkTime(t, e.Next, "2022-01-30 16:00")
+	if e.Snoozed != 0 {
+		t.Errorf("expected the snooze to be cleared once it has fired")
+	}
+	missing, err := Create(testMessage("snooze gardening"), suite.TestDatabase, suite.TestEnv["slackToken"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if missing.Message() != "Sorry, I couldn't find a reminder to snooze" || Queue.Len() != 1 {
+		t.Errorf("expected nothing to be snoozed, got '%s'", missing.Message())
+	}
+	// the laundry was the last to fire, but once it's removed it can't be
+	// snoozed
+	_, err = Create(testMessage("done with the laundry"), suite.TestDatabase, suite.TestEnv["slackToken"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	removed, err := Create(testMessage("snooze 10 minutes"), suite.TestDatabase, suite.TestEnv["slackToken"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if removed.Message() != "Sorry, I couldn't find a reminder to snooze" || Queue.Contains(e) || e.Snoozed > 0 {
+		t.Errorf("expected a removed reminder not to be snoozed, got '%s'", removed.Message())
+	}
+}
+
+// testEventInZone makes an event for the test user as though they were in the
+// time zone, reusing their row rather than adding a user for each zone
+func testEventInZone(tz string, offset int64, message string) (*Event, error) {
+	u, err := findTestUser()
+	if err != nil {
+		return nil, err
+	}
+	u, err = user.CreateFromRecord(map[string]interface{}{
+		"id":        u.ID(),
+		"uhash":     u.Hash(),
+		"tz":        tz,
+		"tz_label":  tz,
+		"tz_offset": offset,
+	}, suite.TestDatabase)
+	if err != nil {
+		return nil, err
+	}
+	e := &Event{
+		Database:  suite.TestDatabase,
+		User:      u,
+		Timestamp: float64(testingNow),
+		Etext:     message,
+		Etype:     "app_mention",
+	}
+	e, err = e.insert()
+	if err != nil {
+		return nil, err
+	}
+	return e, e.updateSchedule()
+}
+
+// findTestUser finds the test user, adding them if they aren't there yet
+func findTestUser() (*user.User, error) {
+	records, err := suite.TestDatabase.QueryRaw(findUser, []interface{}{suite.TestEnv["testUser"]})
+	if err != nil {
+		return nil, err
+	}
+	if len(records) > 0 {
+		return user.CreateFromRecord(records[0], suite.TestDatabase)
+	}
+	return user.Create(&user.UserInit{
+		Database: suite.TestDatabase,
+		Uhash:    suite.TestEnv["testUser"],
+		TZ:       "Africa/Harare",
+		TZLabel:  "Central Africa Time",
+		TZOffset: 7200,
+	})
+}
+
+func parseTestTime(t *testing.T, value string) time.Time {
+	parsed, err := time.ParseInLocation("2006-01-02 15:04", value, &gmt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parsed
+}
+
 func checkTime(t *testing.T, next int64, expects string) {
 	expectsTime, err := time.ParseInLocation("2006-01-02 15:04", expects, &gmt)
 	if err != nil {
@@ -194,6 +772,245 @@ func checkTime(t *testing.T, next int64, expects string) {
 	}
 }
 
+func TestListNumbers(t *testing.T) {
+	ClearQueue()
+	for _, message := range []string{
+		"Remind me to water the plants every day at 8am",
+		"Remind me to call mom on weekends at 7 pm",
+		"Remind me to check the oven in 20 minutes",
+	} {
+		if _, err := Create(testMessage(message), suite.TestDatabase, suite.TestEnv["slackToken"]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tasks := []string{"water the plants", "call mom", "check the oven"}
+	for i, e := range listed(suite.TestEnv["testUser"]) {
+		if task := e.task(); task != tasks[i] {
+			t.Errorf("expected item %d to be '%s', got '%s'", i+1, tasks[i], task)
+		}
+	}
+	e, err := Create(testMessage("snooze 1 for an hour"), suite.TestDatabase, suite.TestEnv["slackToken"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.task() != "water the plants" || e.Snoozed != testingNow+3600 {
+		t.Errorf("expected the plants to be snoozed for an hour, got '%s' until %d", e.task(), e.Snoozed)
+	}
+	e, err = Create(testMessage("done 2"), suite.TestDatabase, suite.TestEnv["slackToken"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.task() != "call mom" || Queue.Contains(e) {
+		t.Errorf("expected calling mom to have been removed, got '%s'", e.task())
+	}
+	e, err = Create(testList(), suite.TestDatabase, suite.TestEnv["slackToken"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(e.Emessage, "every day at 8:00 am, snoozed until Sunday 30 January at 4:25 am") ||
+		!strings.Contains(e.Emessage, "2. check the oven") {
+		t.Errorf("expected the list to be renumbered, got %s", e.Emessage)
+	}
+	e, err = Create(testMessage("done 5"), suite.TestDatabase, suite.TestEnv["slackToken"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(e.Message(), "Sorry") {
+		t.Errorf("expected an apology for a number that isn't on the list, got %s", e.Message())
+	}
+	if e.Destination() != "C02NLG80TEH" {
+		t.Errorf("expected the apology to go to the channel it was asked in, got %s", e.Destination())
+	}
+}
+
+func TestReferences(t *testing.T) {
+	ClearQueue()
+	for _, message := range []string{
+		"Remind me to review report draft every day at 9am",
+		"Remind me to file my monthly expense report every Friday",
+		"Remind me to pick up the laundry every two hours",
+	} {
+		if _, err := Create(testMessage(message), suite.TestDatabase, suite.TestEnv["slackToken"]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	items := listed(suite.TestEnv["testUser"])
+	for _, message := range []string{"done report", "snooze report"} {
+		e, err := Create(testMessage(message), suite.TestDatabase, suite.TestEnv["slackToken"])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(e.Message(), "Sorry, I'm not sure which reminder you mean") ||
+			!strings.Contains(e.Message(), "1. review report draft") ||
+			!strings.Contains(e.Message(), "2. file my monthly expense report") {
+			t.Errorf("expected '%s' to ask which report was meant, got %s", message, e.Message())
+		}
+	}
+	if len(listed(suite.TestEnv["testUser"])) != 3 || items[1].Snoozed > 0 {
+		t.Errorf("expected an ambiguous request to leave the reminders alone")
+	}
+	e, err := Create(testMessage(fmt.Sprintf("done #%d", items[1].ID)), suite.TestDatabase, suite.TestEnv["slackToken"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e != items[1] || Queue.Contains(e) {
+		t.Errorf("expected reminder #%d to have been removed", items[1].ID)
+	}
+	e, err = Create(testMessage("change 2 to every day at 6pm"), suite.TestDatabase, suite.TestEnv["slackToken"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e != items[2] || e.Message() != "I'll remind you every day at 6:00 pm" {
+		t.Errorf("expected the laundry to be changed to every day at 6:00 pm, got %s", e.Message())
+	}
+	if e.task() != "pick up the laundry" {
+		t.Errorf("expected the changed reminder to keep its task, got '%s'", e.task())
+	}
+	e, err = Create(testMessage(fmt.Sprintf("done #%d", items[1].ID)), suite.TestDatabase, suite.TestEnv["slackToken"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Message() != fmt.Sprintf("Sorry, there's no reminder #%d on your todo list", items[1].ID) {
+		t.Errorf("expected an apology for a reminder that's been removed, got %s", e.Message())
+	}
+}
+
+func TestConfirmUpdate(t *testing.T) {
+	ClearQueue()
+	dentist, err := Create(testMessage("Remind me to call the dentist every day at 9am"), suite.TestDatabase, suite.TestEnv["slackToken"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		message string
+		answer  string
+		task    string
+		count   int
+	}{
+		{"Remind me to call mom every day at 6pm", "new", "call mom", 2},
+		{"Remind me to call the dentist every Monday at 9am", "update", "call the dentist", 2},
+	} {
+		e, err := Create(testMessage(tc.message), suite.TestDatabase, suite.TestEnv["slackToken"])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(e.Message(), "Did you mean to update '") || Queue.Contains(e) {
+			t.Fatalf("expected '%s' to ask whether to update a reminder, got %s", tc.message, e.Message())
+		}
+		e, err = Create(testMessage(tc.answer), suite.TestDatabase, suite.TestEnv["slackToken"])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if e.task() != tc.task || !Queue.Contains(e) {
+			t.Errorf("expected answering '%s' to schedule '%s', got '%s'", tc.answer, tc.task, e.task())
+		}
+		if count := len(listed(suite.TestEnv["testUser"])); count != tc.count {
+			t.Errorf("expected %d reminders after answering '%s', got %d", tc.count, tc.answer, count)
+		}
+	}
+	if dentist.Message() != "I'll remind you every Monday at 9:00 am" {
+		t.Errorf("expected the dentist reminder to have been updated, got %s", dentist.Message())
+	}
+	e, err := Create(testMessage("update"), suite.TestDatabase, suite.TestEnv["slackToken"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.HasPrefix(e.Message(), "I'll remind you") {
+		t.Errorf("expected an answer with no question pending to change nothing, got %s", e.Message())
+	}
+}
+
+func TestPreview(t *testing.T) {
+	ClearQueue()
+	loc, err := time.LoadLocation("Africa/Harare")
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := time.Date(2022, time.March, 1, 9, 0, 0, 0, loc)
+	p, err := PreviewMessage("Remind me to water the plants every 2 days at 8am until Saturday", "Africa/Harare", at, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Command.Intent != IntentAdd || p.Task != "water the plants" || p.Schedule != "every 2 day(s) at 8:00 am until Saturday 5 March" {
+		t.Errorf("unexpected preview %s '%s' '%s'", p.Command.Intent, p.Task, p.Schedule)
+	}
+	if len(p.Next) != 2 {
+		t.Fatalf("expected the reminder to fire twice before it ends, got %d time(s)", len(p.Next))
+	}
+	for i, expects := range []string{"2022-03-02 08:00", "2022-03-04 08:00"} {
+		if next := p.Next[i].Format("2006-01-02 15:04"); next != expects {
+			t.Errorf("expected time %d to be %s, got %s", i+1, expects, next)
+		}
+	}
+	if Queue.Len() != 0 || now() != testingNow {
+		t.Errorf("expected a preview to leave the queue and the clock alone")
+	}
+	p, err = PreviewMessage("done 3", "UTC", at, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Command.Intent != IntentComplete || len(p.Next) > 0 {
+		t.Errorf("expected a preview of 'done 3' to complete without a schedule, got %s", p.Command.Intent)
+	}
+}
+
+func TestPurgeInactive(t *testing.T) {
+	ClearQueue()
+	create := func(message string) *Event {
+		e, err := Create(testMessage(message), suite.TestDatabase, suite.TestEnv["slackToken"])
+		if err != nil {
+			t.Fatal(err)
+		}
+		return e
+	}
+	active := create("Remind me to sweep the porch every day at 7 am")
+	snoozed := create("Remind me to oil the hinges every week")
+	create("snooze the hinges for 30 minutes")
+	completed := create("Remind me to post the parcel in 20 minutes")
+	completed.complete()
+	removed := create("Remind me to call the landlord every Monday")
+	create("done calling the landlord")
+	recent := create("Remind me to tune the guitar every Friday")
+	create("done with the guitar")
+	if snoozed.Snoozed < 1 || removed.Schedule > 0 || recent.Schedule > 0 {
+		t.Fatalf("expected the hinges to be snoozed and the landlord and guitar removed")
+	}
+	for _, e := range []*Event{active, snoozed, completed, removed} {
+		_, err := suite.TestDatabase.Exec("update events set updated_at = now() - interval 40 day where id = ?", []interface{}{e.ID})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, err := PurgeInactive(suite.TestDatabase, 30)
+	if err != nil {
+		t.Fatal(err)
+	}
+	records, err := Stored(suite.TestDatabase, suite.TestEnv["testUser"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored := make(map[int64]bool)
+	for _, rec := range records {
+		stored[utils.Int64Interface(rec["id"])] = true
+	}
+	for _, tc := range []struct {
+		name string
+		e    *Event
+		kept bool
+	}{
+		{"an active event", active, true},
+		{"a snoozed event", snoozed, true},
+		{"a recently removed event", recent, true},
+		{"an old completed event", completed, false},
+		{"an old removed event", removed, false},
+	} {
+		if stored[tc.e.ID] != tc.kept {
+			t.Errorf("expected %s to be kept: %v", tc.name, tc.kept)
+		}
+	}
+	ClearQueue()
+}
+
 // Be sure to run these last two tests last!
 func TestBootstrapQueue(t *testing.T) {
 	ClearQueue()
@@ -262,11 +1079,11 @@ func TestCheckQueueUsers(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	i := Queue.Back()
-	e, ok := i.Value.(*Event)
-	if !ok {
+	events := Queue.Events()
+	if len(events) < 1 {
 		t.Fatalf("event not found")
 	}
+	e := events[len(events)-1]
 	if len(e.User.TZ()) < 1 {
 		t.Errorf("expected timezone for event-user to be populated, got empty string")
 	}
@@ -299,11 +1116,9 @@ func checkQueueProcessResult(result []map[string]string, e *Event) error {
 }
 
 func checkEventNotInQueue(e *Event) error {
-	for i := Queue.Front(); i != nil; i = i.Next() {
-		if k, ok := i.Value.(*Event); ok {
-			if k.ID == e.ID {
-				return fmt.Errorf("expected event to have been removed, found event #%d in queue", k.ID)
-			}
+	for _, k := range Queue.Events() {
+		if k.ID == e.ID {
+			return fmt.Errorf("expected event to have been removed, found event #%d in queue", k.ID)
 		}
 	}
 	return nil
@@ -450,6 +1265,15 @@ func testList() string {
 	)
 }
 
+func testMessage(message string) string {
+	return strings.Replace(
+		payload(),
+		"[message]",
+		message,
+		1,
+	)
+}
+
 func payload() string {
 	return strings.Replace(tests.TestEventPayload, "[timestamp]", fmt.Sprintf("%d", testingNow), 1)
 }
@@ -1,34 +1,168 @@
 package event
 
 import (
-	"container/list"
 	"regexp"
+	"time"
 
 	"github.com/blainemoser/todobot/user"
 )
 
 const createEvent = `insert into events (channel, ts, schedule, etext, etype, user_id) values (?,?,?,?,?,?)`
 
-const updateEvent = `update events set channel = ?, ts = ?, schedule = ?, etext = ?, etype = ? where id = ?`
+const updateEvent = `update events set channel = ?, ts = ?, schedule = ?, cron = ?, once = ?, ends_at = ?, remaining = ?, next_at = ?, last_fired_at = ?, snoozed_until = ?, etext = ?, etype = ? where id = ?`
+
+const completeEvent = `update events set completed_at = CURRENT_TIMESTAMP where id = ?`
+
+const reopenEvent = `update events set completed_at = null where id = ?`
+
+const findEvent = `select e.*, u.uhash from events e join users u on u.id = e.user_id where e.id = ? and u.uhash = ?`
+
+// lastFiredEvent leaves out events that have been removed or have run their
+// course, though not one-off events, which can be snoozed once they've fired
+const lastFiredEvent = `select e.id from events e join users u on u.id = e.user_id where u.uhash = ? and e.last_fired_at is not null and ((e.completed_at is null and e.schedule > 0) or e.once = 1) order by e.last_fired_at desc limit 1`
 
 const findUser = `select * from users where uhash = ?`
 
-const bootQueueQuery = `select e.*, u.uhash from events e join users u on u.id = e.user_id where e.schedule > 0`
+const bootQueueQuery = `select e.*, u.uhash from events e join users u on u.id = e.user_id where (e.schedule > 0 or e.once = 1) and e.completed_at is null`
+
+const storedEvents = `select e.*, u.uhash from events e left join users u on u.id = e.user_id order by e.id`
+
+const storedUserEvents = `select e.*, u.uhash from events e join users u on u.id = e.user_id where u.uhash = ? order by e.id`
+
+const purgeInactive = `delete from events where (completed_at is not null or (ifnull(schedule, 0) < 1 and ifnull(once, 0) = 0)) and ifnull(snoozed_until, 0) = 0 and updated_at < now() - interval ? day`
+
+const week int = 604800
 
 const day int = 86400
 
 const hour int = 3600
 
+const minute int = 60
+
+// lookahead is how many seconds early events may be processed
+const lookahead int64 = 5
+
+// the catch-up policies for occurrences missed while the bot was down
+const (
+	CatchUpOnce = "once"
+	CatchUpAll  = "all"
+	CatchUpSkip = "skip"
+)
+
+// maxCatchUp caps how many missed occurrences of an event are fired under the
+// "all" policy
+const maxCatchUp = 24
+
+// confirmFor is how many seconds a question about updating a reminder waits
+// for an answer
+const confirmFor int64 = 600
+
+// untilWords is how far into the clause after "until" its date is looked for,
+// far enough for "until the 3rd of March"
+const untilWords = 4
+
 const tFormat = `2006-01-02 15:04:05`
 
+const punctuation = `.,!?;:'"()`
+
 var (
 	testingMode                 = false
 	testingNow  int64           = 1643505910
+	catchUp                     = CatchUpOnce
 	reminders   map[string]bool = map[string]bool{
 		"reminder": true,
 		"remind":   true,
 		"schedule": true,
 	}
+	// preamble is the wording of a request that isn't part of the task
+	preamble map[string]bool = map[string]bool{
+		"please":   true,
+		"remind":   true,
+		"me":       true,
+		"to":       true,
+		"schedule": true,
+		"rather":   true,
+	}
+	// scheduleWords begin the part of a request that says when it happens
+	scheduleWords map[string]bool = map[string]bool{
+		"every":    true,
+		"each":     true,
+		"until":    true,
+		"today":    true,
+		"tonight":  true,
+		"tomorrow": true,
+		"twice":    true,
+	}
+	// recurrences say how often an event happens, after which a duration or
+	// number of times is taken to be when it ends
+	recurrences map[string]bool = map[string]bool{
+		"every":   true,
+		"each":    true,
+		"cron":    true,
+		"hourly":  true,
+		"daily":   true,
+		"weekly":  true,
+		"monthly": true,
+		"yearly":  true,
+	}
+	// edits change the schedule of a reminder referred to by number or ID
+	edits map[string]bool = map[string]bool{
+		"update": true,
+		"change": true,
+		"edit":   true,
+	}
+	// speakers are skipped at the start of a command, as in "I've finished the
+	// laundry"
+	speakers map[string]bool = map[string]bool{
+		"i":      true,
+		"i've":   true,
+		"ive":    true,
+		"i'm":    true,
+		"im":     true,
+		"have":   true,
+		"am":     true,
+		"just":   true,
+		"please": true,
+	}
+	helpPhrases map[string]bool = map[string]bool{
+		"help":               true,
+		"usage":              true,
+		"commands":           true,
+		"what can you do":    true,
+		"how does this work": true,
+	}
+	listPhrases map[string]bool = map[string]bool{
+		"list":              true,
+		"todo":              true,
+		"todos":             true,
+		"todo list":         true,
+		"my list":           true,
+		"my todo list":      true,
+		"my reminders":      true,
+		"show my list":      true,
+		"show my todo list": true,
+		"show my reminders": true,
+		"list my reminders": true,
+	}
+	// explicitUpdates mark a request as changing an existing reminder
+	explicitUpdates map[string]bool = map[string]bool{
+		"rather":  true,
+		"instead": true,
+		"update":  true,
+		"change":  true,
+	}
+	// confirmations answer whether to update a reminder (true) or create a
+	// new one (false)
+	confirmations map[string]bool = map[string]bool{
+		"update":                true,
+		"update it":             true,
+		"new":                   false,
+		"new one":               false,
+		"new reminder":          false,
+		"create":                false,
+		"create new":            false,
+		"create a new reminder": false,
+	}
 	cancellations map[string]bool = map[string]bool{
 		"cancel":        true,
 		"done":          true,
@@ -101,11 +235,155 @@ var (
 		"2":  2,
 		"1":  1,
 	}
+	weekdayNames map[string]time.Weekday = map[string]time.Weekday{
+		"monday":    time.Monday,
+		"mon":       time.Monday,
+		"tuesday":   time.Tuesday,
+		"tue":       time.Tuesday,
+		"tues":      time.Tuesday,
+		"wednesday": time.Wednesday,
+		"wed":       time.Wednesday,
+		"thursday":  time.Thursday,
+		"thu":       time.Thursday,
+		"thur":      time.Thursday,
+		"thurs":     time.Thursday,
+		"friday":    time.Friday,
+		"fri":       time.Friday,
+		"saturday":  time.Saturday,
+		"sat":       time.Saturday,
+		"sunday":    time.Sunday,
+		"sun":       time.Sunday,
+	}
+	weekdayGroups map[string][]time.Weekday = map[string][]time.Weekday{
+		"weekday": {time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+		"weekend": {time.Saturday, time.Sunday},
+	}
+	months map[string]time.Month = map[string]time.Month{
+		"january":   time.January,
+		"jan":       time.January,
+		"february":  time.February,
+		"feb":       time.February,
+		"march":     time.March,
+		"mar":       time.March,
+		"april":     time.April,
+		"apr":       time.April,
+		"may":       time.May,
+		"june":      time.June,
+		"jun":       time.June,
+		"july":      time.July,
+		"jul":       time.July,
+		"august":    time.August,
+		"aug":       time.August,
+		"september": time.September,
+		"sep":       time.September,
+		"sept":      time.September,
+		"october":   time.October,
+		"oct":       time.October,
+		"november":  time.November,
+		"nov":       time.November,
+		"december":  time.December,
+		"dec":       time.December,
+	}
+	units map[string]string = map[string]string{
+		"minute": "minute",
+		"min":    "minute",
+		"hour":   "hour",
+		"hr":     "hour",
+		"day":    "day",
+		"week":   "week",
+		"month":  "month",
+		"year":   "year",
+		"yr":     "year",
+	}
+	unitSeconds map[string]int64 = map[string]int64{
+		"minute": int64(minute),
+		"hour":   int64(hour),
+		"day":    int64(day),
+		"week":   int64(week),
+	}
+	cronMacros map[string]string = map[string]string{
+		"@yearly":   "0 0 1 1 *",
+		"@annually": "0 0 1 1 *",
+		"@monthly":  "0 0 1 * *",
+		"@weekly":   "0 0 * * 0",
+		"@daily":    "0 0 * * *",
+		"@midnight": "0 0 * * *",
+		"@hourly":   "0 * * * *",
+	}
+	cronFields []cronField = []cronField{
+		{min: 0, max: 59},
+		{min: 0, max: 23},
+		{min: 1, max: 31},
+		{min: 1, max: 12, names: map[string]int{
+			"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+			"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+		}},
+		{min: 0, max: 7, names: map[string]int{
+			"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+		}},
+	}
 	Users          map[int64]*user.User = make(map[int64]*user.User)
 	NewLines                            = regexp.MustCompile(`\n+`)
 	MultiSpace                          = regexp.MustCompile(`[ ]{2,}`)
-	Queue          *list.List           = list.New()
+	Queue          *EventQueue          = NewQueue()
 	removeTag                           = regexp.MustCompile(`<@(.*?)>`)
 	twentyFourHour                      = regexp.MustCompile(`(.*?)h(.*?)`)
 	colonTime                           = regexp.MustCompile(`(.*?):(.*?)`)
+	meridiemTime                        = regexp.MustCompile(`^(\d{1,2}(?:[:h]\d{2})?)(am|pm)$`)
+	ordinalDay                          = regexp.MustCompile(`^(\d{1,2})(st|nd|rd|th)?$`)
+	isoDate                             = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+	cronExpression                      = regexp.MustCompile("(?i)\\bcron\\s+([\"'“”‘’`]?)([^\"'“”‘’`]+)")
+)
+
+var (
+	// stopWords are left out when comparing reminders, along with numbers
+	// and units of time
+	stopWords = map[string]bool{
+		"a": true, "an": true, "the": true, "and": true, "or": true, "but": true,
+		"of": true, "in": true, "on": true, "at": true, "for": true, "with": true,
+		"by": true, "from": true, "up": true, "about": true, "into": true, "out": true,
+		"my": true, "our": true, "your": true, "his": true, "her": true, "their": true,
+		"you": true, "we": true, "it": true, "its": true, "im": true, "this": true,
+		"that": true, "these": true, "those": true, "is": true, "are": true, "was": true,
+		"be": true, "been": true, "do": true, "did": true, "some": true, "any": true,
+		"all": true, "just": true, "now": true, "then": true, "please": true, "am": true,
+		"pm": true, "each": true, "remind": true, "reminder": true, "reminders": true,
+		"schedule": true, "rather": true, "instead": true, "again": true,
+	}
+	// synonyms are taken to mean the same as the word they map to when
+	// reminders are compared; more can be added with AddSynonym
+	synonyms = map[string]string{
+		"phone":    "call",
+		"ring":     "call",
+		"rang":     "call",
+		"mum":      "mom",
+		"mother":   "mom",
+		"father":   "dad",
+		"doc":      "doctor",
+		"dr":       "doctor",
+		"meds":     "medication",
+		"medicine": "medication",
+		"pills":    "medication",
+		"tablets":  "medication",
+		"washing":  "laundry",
+		"canceled": "cancel",
+	}
+	// the suffixes replaced by the second and third steps of the Porter
+	// stemmer, longest first where one ends with another
+	longSuffixes = [][2]string{
+		{"ational", "ate"}, {"tional", "tion"}, {"enci", "ence"}, {"anci", "ance"},
+		{"izer", "ize"}, {"abli", "able"}, {"alli", "al"}, {"entli", "ent"},
+		{"eli", "e"}, {"ousli", "ous"}, {"ization", "ize"}, {"ation", "ate"},
+		{"ator", "ate"}, {"alism", "al"}, {"iveness", "ive"}, {"fulness", "ful"},
+		{"ousness", "ous"}, {"aliti", "al"}, {"iviti", "ive"}, {"biliti", "ble"},
+	}
+	shortSuffixes = [][2]string{
+		{"icate", "ic"}, {"ative", ""}, {"alize", "al"}, {"iciti", "ic"},
+		{"ical", "ic"}, {"ful", ""}, {"ness", ""},
+	}
+	// the suffixes removed by the fourth step
+	strippedSuffixes = []string{
+		"ement", "ment", "ent", "ance", "ence", "able", "ible", "ant", "ion",
+		"ism", "ate", "iti", "ous", "ive", "ize", "al", "er", "ic", "ou",
+	}
 )
@@ -0,0 +1,160 @@
+package event
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Intent is what a message asks the bot to do
+type Intent int
+
+const (
+	IntentUnknown Intent = iota
+	IntentAdd
+	IntentUpdate
+	IntentComplete
+	IntentSnooze
+	IntentList
+	IntentHelp
+	IntentConfirm
+)
+
+var intentNames = map[Intent]string{
+	IntentUnknown:  "unknown",
+	IntentAdd:      "add",
+	IntentUpdate:   "update",
+	IntentComplete: "complete",
+	IntentSnooze:   "snooze",
+	IntentList:     "list",
+	IntentHelp:     "help",
+	IntentConfirm:  "confirm",
+}
+
+func (i Intent) String() string {
+	return intentNames[i]
+}
+
+// Command is a message parsed into what it asks for and the details needed
+// to do it
+type Command struct {
+	Intent Intent
+	// Reference names a reminder by its number on the todo list ("3") or by
+	// its ID ("#12")
+	Reference string
+	// Text is the rest of the message: the reminder to add or update it to,
+	// the reminder to complete, or how long to snooze for
+	Text string
+	// Answer is whether to update the reminder asked about, for IntentConfirm
+	Answer bool
+}
+
+// ParseCommand works out what the message asks for. The first words decide
+// it: "done", "snooze" and "update 3" are commands in their own right, and a
+// message is only a new reminder if it asks to be reminded. "Remind me to get
+// this done every day" is a new reminder, while "tax forms done" completes
+// one (but "is it done?" doesn't). Anything else is IntentUnknown, which gets
+// help rather than a guess.
+func ParseCommand(text string) Command {
+	words := strings.Fields(text)
+	lower := make([]string, len(words))
+	for i, v := range words {
+		lower[i] = strings.ToLower(strings.Trim(v, punctuation))
+	}
+	phrase := strings.Join(lower, " ")
+	switch {
+	case len(words) < 1:
+		return Command{Intent: IntentUnknown}
+	case helpPhrases[phrase]:
+		return Command{Intent: IntentHelp}
+	case listPhrases[phrase]:
+		return Command{Intent: IntentList}
+	}
+	if answer, ok := confirmations[phrase]; ok {
+		return Command{Intent: IntentConfirm, Answer: answer}
+	}
+	start := 0
+	for start < len(lower)-1 && speakers[lower[start]] {
+		start++
+	}
+	first, rest, restLower := lower[start], words[start+1:], lower[start+1:]
+	switch {
+	case first == "snooze":
+		return snoozeCommand(rest, restLower)
+	case isCancellation(first):
+		return completeCommand(rest, restLower)
+	case edits[first] && len(rest) > 0 && isReference(restLower[0]):
+		return Command{Intent: IntentUpdate, Reference: restLower[0], Text: withoutTo(rest[1:])}
+	}
+	if add, update := requestsReminder(lower); add {
+		if update {
+			return Command{Intent: IntentUpdate, Text: text}
+		}
+		return Command{Intent: IntentAdd, Text: text}
+	}
+	if len(lower) > 1 && isCancellation(lower[len(lower)-1]) && !strings.HasSuffix(text, "?") {
+		return Command{Intent: IntentComplete, Text: strings.Join(words[:len(words)-1], " ")}
+	}
+	return Command{Intent: IntentUnknown, Text: text}
+}
+
+// snoozeCommand reads "snooze 2 for an hour"; a number is only a reference
+// to the todo list if it isn't followed by a unit of time, as in "snooze 30
+// minutes"
+func snoozeCommand(words, lower []string) Command {
+	c := Command{Intent: IntentSnooze, Text: strings.Join(words, " ")}
+	if len(lower) < 1 || !isReference(lower[0]) {
+		return c
+	}
+	if len(lower) > 1 && len(units[strings.TrimSuffix(lower[1], "s")]) > 0 {
+		return c
+	}
+	c.Reference = lower[0]
+	c.Text = strings.Join(words[1:], " ")
+	return c
+}
+
+// completeCommand reads "done 3", "done #12" and "done with the report"
+func completeCommand(words, lower []string) Command {
+	if len(lower) > 0 && lower[0] == "with" {
+		words, lower = words[1:], lower[1:]
+	}
+	if len(lower) < 1 {
+		return Command{Intent: IntentUnknown}
+	}
+	if len(lower) == 1 && isReference(lower[0]) || strings.HasPrefix(lower[0], "#") && isReference(lower[0]) {
+		return Command{Intent: IntentComplete, Reference: lower[0]}
+	}
+	return Command{Intent: IntentComplete, Text: strings.Join(words, " ")}
+}
+
+// requestsReminder reports whether the words ask to be reminded, and whether
+// they explicitly ask for an existing reminder to be changed ("rather remind
+// me to eat every three hours", "remind me to eat every hour instead")
+func requestsReminder(lower []string) (add, update bool) {
+	for i, v := range lower {
+		if reminders[v] {
+			add = true
+			for _, before := range lower[:i] {
+				update = update || explicitUpdates[before]
+			}
+			break
+		}
+	}
+	for _, v := range lower {
+		update = update || v == "instead"
+	}
+	return add, add && update
+}
+
+// isReference reports whether the word is a list number or an ID
+func isReference(word string) bool {
+	_, err := strconv.ParseInt(strings.TrimPrefix(word, "#"), 10, 64)
+	return err == nil
+}
+
+func withoutTo(words []string) string {
+	if len(words) > 0 && strings.ToLower(words[0]) == "to" {
+		words = words[1:]
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,84 @@
+package event
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseCommand(t *testing.T) {
+	for _, tc := range []struct {
+		text      string
+		intent    Intent
+		reference string
+		rest      string
+	}{
+		{"Remind me to call my lawyer every day", IntentAdd, "", "Remind me to call my lawyer every day"},
+		{"remind me to get this done every day", IntentAdd, "", "remind me to get this done every day"},
+		{"Please remind me to cancel the gym membership tomorrow", IntentAdd, "", "Please remind me to cancel the gym membership tomorrow"},
+		{"Schedule a call with Bob on Monday at 3pm", IntentAdd, "", "Schedule a call with Bob on Monday at 3pm"},
+		{"Rather remind me to eat every three hours", IntentUpdate, "", "Rather remind me to eat every three hours"},
+		{"remind me to eat every hour instead", IntentUpdate, "", "remind me to eat every hour instead"},
+		{"remind me to change the oil every 3 months", IntentAdd, "", "remind me to change the oil every 3 months"},
+		{"change 2 to every day at 6pm", IntentUpdate, "2", "every day at 6pm"},
+		{"update #12 every Monday", IntentUpdate, "#12", "every Monday"},
+		{"done calling my lawyer", IntentComplete, "", "calling my lawyer"},
+		{"Done with the report.", IntentComplete, "", "the report."},
+		{"Cancelled: the dentist", IntentComplete, "", "the dentist"},
+		{"canceled the gym", IntentComplete, "", "the gym"},
+		{"I've finished the laundry!", IntentComplete, "", "the laundry!"},
+		{"removing the plants reminder", IntentComplete, "", "the plants reminder"},
+		{"Completed.", IntentUnknown, "", ""},
+		{"tax forms done", IntentComplete, "", "tax forms"},
+		{"done 3", IntentComplete, "3", ""},
+		{"done #12", IntentComplete, "#12", ""},
+		{"done", IntentUnknown, "", ""},
+		{"snooze", IntentSnooze, "", ""},
+		{"snooze 30 minutes", IntentSnooze, "", "30 minutes"},
+		{"snooze 2 for an hour", IntentSnooze, "2", "for an hour"},
+		{"snooze the laundry until 5pm", IntentSnooze, "", "the laundry until 5pm"},
+		{"list", IntentList, "", ""},
+		{"Show my todo list", IntentList, "", ""},
+		{"help", IntentHelp, "", ""},
+		{"What can you do?", IntentHelp, "", ""},
+		{"update", IntentConfirm, "", ""},
+		{"new", IntentConfirm, "", ""},
+		{"I think you're pretty cool!", IntentUnknown, "", "I think you're pretty cool!"},
+		{"the laundry is done but remind me later", IntentAdd, "", "the laundry is done but remind me later"},
+		{"", IntentUnknown, "", ""},
+	} {
+		c := ParseCommand(tc.text)
+		if c.Intent != tc.intent || c.Reference != tc.reference || c.Text != tc.rest {
+			t.Errorf(
+				"expected '%s' to parse as %s '%s' '%s', got %s '%s' '%s'",
+				tc.text, tc.intent, tc.reference, tc.rest, c.Intent, c.Reference, c.Text,
+			)
+		}
+	}
+	if c := ParseCommand("new reminder"); !(c.Intent == IntentConfirm && !c.Answer) {
+		t.Errorf("expected 'new reminder' to answer not to update, got %s %v", c.Intent, c.Answer)
+	}
+}
+
+func TestHelp(t *testing.T) {
+	ClearQueue()
+	e, err := Create(testMessage("help"), suite.TestDatabase, suite.TestEnv["slackToken"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Message() != "Here's what I can do for you:" || !strings.Contains(e.MessageBody(), "Try asking me") {
+		t.Errorf("expected help, got %s%s", e.Message(), e.MessageBody())
+	}
+	_, err = Create(testMessage("Remind me to get this done every day"), suite.TestDatabase, suite.TestEnv["slackToken"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, message := range []string{"done", "is it done?"} {
+		e, err = Create(testMessage(message), suite.TestDatabase, suite.TestEnv["slackToken"])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(e.Message(), "couldn't understand") || Queue.Len() != 1 {
+			t.Errorf("expected '%s' to get help and leave the reminder alone, got %s", message, e.Message())
+		}
+	}
+}
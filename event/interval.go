@@ -0,0 +1,176 @@
+package event
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// everyInterval reads the interval that follows "every", as in "every 30
+// minutes", "every other week" or "every month"
+func (e *Event) everyInterval() (int, string) {
+	words := e.words()
+	for i, v := range words {
+		if v != "every" {
+			continue
+		}
+		number := 0
+		for j := i + 1; j < len(words) && j < i+3; j++ {
+			w := strings.Trim(words[j], punctuation)
+			if unit, ok := units[strings.TrimSuffix(w, "s")]; ok {
+				return number, unit
+			}
+			if w == "other" {
+				number = 2
+				continue
+			}
+			if number = parseNumber(w); number < 1 {
+				break
+			}
+		}
+	}
+	return 0, ""
+}
+
+func (e *Event) scheduleMinute(number int) {
+	if number < 1 {
+		e.Emessage = "every minute"
+		e.Schedule = int64(minute)
+		return
+	}
+	e.Emessage = fmt.Sprintf("every %d minute(s)", number)
+	e.Schedule = int64(number * minute)
+}
+
+func (e *Event) scheduleWeek(number int) {
+	if number < 1 {
+		e.Emessage = "every week"
+		e.Schedule = int64(week)
+	} else {
+		e.Emessage = fmt.Sprintf("every %d week(s)", number)
+		e.Schedule = int64(number * week)
+	}
+	e.hasSpecificTime()
+}
+
+func (e *Event) scheduleMonth(number int) {
+	if number < 1 {
+		number = 1
+		e.Emessage = "every month"
+	} else {
+		e.Emessage = fmt.Sprintf("every %d month(s)", number)
+	}
+	e.months = number
+	_, e.monthDay = e.monthAnchor()
+	e.Emessage = fmt.Sprintf("%s on the %s", e.Emessage, ordinal(e.monthDay))
+	e.startMonthly()
+}
+
+func (e *Event) scheduleYear(number int) {
+	if number < 1 {
+		number = 1
+		e.Emessage = "every year"
+	} else {
+		e.Emessage = fmt.Sprintf("every %d year(s)", number)
+	}
+	e.months = 12 * number
+	e.month, e.monthDay = e.monthAnchor()
+	e.Emessage = fmt.Sprintf("%s on %d %s", e.Emessage, e.monthDay, e.startMonth())
+	e.startMonthly()
+}
+
+// monthAnchor finds the day of the month asked for ("on the 1st", "on the
+// 15th"), falling back to the day the event was sent, and the month if it's
+// named with the day ("on 15 April", "on the 15th of April")
+func (e *Event) monthAnchor() (time.Month, int) {
+	words := make([]string, 0)
+	for _, v := range e.words() {
+		words = append(words, strings.Trim(v, punctuation))
+	}
+	for i, v := range words {
+		if m, ok := months[v]; ok {
+			if d, _ := dayAround(words, i); d > 0 {
+				return m, d
+			}
+		}
+	}
+	for _, v := range words {
+		parts := ordinalDay.FindStringSubmatch(v)
+		if len(parts) == 3 && len(parts[2]) > 0 {
+			if d := parseOrdinal(parts[0]); d > 0 {
+				return 0, d
+			}
+		}
+	}
+	return 0, e.reference().Day()
+}
+
+// startMonth is the month of the first occurrence: the one asked for, or
+// else the one in which the event was sent
+func (e *Event) startMonth() time.Month {
+	if e.month > 0 {
+		return e.month
+	}
+	return e.reference().Month()
+}
+
+// startMonthly sets the first occurrence of a monthly or yearly event: the
+// anchored day of the month in which the event was sent (or of the month asked
+// for), or the first one after that which hasn't passed yet
+func (e *Event) startMonthly() {
+	// the calendar determines the actual dates, the schedule is nominal
+	e.Schedule = int64(e.months * 30 * day)
+	ref := e.reference()
+	start := time.Date(
+		ref.Year(), e.startMonth(), clampDay(ref.Year(), e.startMonth(), e.monthDay),
+		ref.Hour(), ref.Minute(), ref.Second(), 0, ref.Location(),
+	)
+	if t := e.timeOfDay(); len(t) > 0 {
+		start = e.withTimeOfDay(start)
+		e.Emessage = fmt.Sprintf("%s at %s", e.Emessage, start.Format("3:04 pm"))
+	}
+	for start.Unix() <= e.now() {
+		start = time.Unix(e.addMonths(start.Unix()), 0).In(ref.Location())
+	}
+	e.Timestamp = float64(start.Unix())
+	e.Next = start.Unix()
+	e.nextSet = true
+}
+
+// addMonths moves ts forward by the event's number of months, keeping to the
+// anchored day of the month where the month is long enough
+func (e *Event) addMonths(ts int64) int64 {
+	t := time.Unix(ts, 0).In(e.location())
+	first := time.Date(t.Year(), t.Month()+time.Month(e.months), 1, 0, 0, 0, 0, t.Location())
+	d := e.monthDay
+	if d < 1 {
+		d = t.Day()
+	}
+	return time.Date(
+		first.Year(), first.Month(), clampDay(first.Year(), first.Month(), d),
+		t.Hour(), t.Minute(), t.Second(), 0, t.Location(),
+	).Unix()
+}
+
+// clampDay limits d to the number of days in the given month
+func clampDay(year int, month time.Month, d int) int {
+	last := time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	if d > last {
+		return last
+	}
+	return d
+}
+
+func ordinal(d int) string {
+	suffix := "th"
+	switch {
+	case d%100 >= 11 && d%100 <= 13:
+	case d%10 == 1:
+		suffix = "st"
+	case d%10 == 2:
+		suffix = "nd"
+	case d%10 == 3:
+		suffix = "rd"
+	}
+	return fmt.Sprintf("%d%s", d, suffix)
+}
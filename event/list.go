@@ -0,0 +1,182 @@
+package event
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func (ei *EventInit) list() (*Event, error) {
+	ei.Schedule = 0
+	e, err := ei.create()
+	if err != nil {
+		return nil, err
+	}
+	e.intent = IntentList
+	return e.eventList()
+}
+
+// eventList numbers the user's reminders, so that they can be referred to as
+// "done 3" or "snooze 2"
+func (e *Event) eventList() (*Event, error) {
+	e.verbatim = true
+	eventList := listed(e.User.Hash())
+	if len(eventList) < 1 {
+		e.Emessage = "Your todo list is empty!"
+		return e, nil
+	}
+	message := make([]string, 0)
+	for i, v := range eventList {
+		message = append(message, fmt.Sprintf("%d. %s", i+1, v.listItem()))
+	}
+	e.Emessage = fmt.Sprintf("Your todo list:\n%s", strings.Join(message, "\n"))
+	return e, nil
+}
+
+// listed returns the reminders on the user's todo list, in the order they are
+// numbered
+func listed(uhash string) []*Event {
+	result := make([]*Event, 0)
+	for _, v := range Queue.userEvents(uhash) {
+		if v.Schedule > 0 || v.Once {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+// listItem describes the reminder as "water the plants (#12): every day at
+// 8:00 am, next on Monday 31 January at 8:00 am"
+func (e *Event) listItem() string {
+	next := time.Unix(e.Next, 0).In(e.location()).Format("Monday 2 January at 3:04 pm")
+	if e.Snoozed > 0 {
+		next = fmt.Sprintf("snoozed until %s", next)
+	} else {
+		next = fmt.Sprintf("next on %s", next)
+	}
+	return fmt.Sprintf("%s (#%d): %s%s, %s", e.task(), e.ID, e.summary, e.describeBounds(), next)
+}
+
+// task is what the user asked to be reminded of, without the request itself
+// or the schedule: "pick up the laundry" for "remind me to pick up the
+// laundry every two hours"
+func (e *Event) task() string {
+	words := strings.Fields(e.echo())
+	lower := make([]string, len(words))
+	for i, v := range words {
+		lower[i] = strings.ToLower(strings.Trim(v, punctuation))
+	}
+	start := 0
+	for start < len(lower) && preamble[lower[start]] {
+		start++
+	}
+	end := len(words)
+	for i := start; i < len(lower); i++ {
+		if startsSchedule(lower, i) {
+			end = i
+			break
+		}
+	}
+	if end <= start {
+		return e.echo()
+	}
+	return strings.TrimRight(strings.Join(words[start:end], " "), punctuation)
+}
+
+// startsSchedule reports whether the word at i begins the part of a request
+// that says when it should happen
+func startsSchedule(words []string, i int) bool {
+	v := words[i]
+	if scheduleWords[v] {
+		return true
+	}
+	if i+1 >= len(words) {
+		return false
+	}
+	following := words[i+1]
+	switch v {
+	case "at":
+		return len(following) > 0 && following[0] >= '0' && following[0] <= '9' || numbers[following] > 0
+	case "in", "for":
+		return parseNumber(following) > 0
+	case "cron":
+		return strings.IndexAny(following, "0123456789*@“‘`") == 0
+	case "next", "this":
+		_, ok := weekdayNames[following]
+		return ok
+	case "on":
+		_, weekday := weekdayNames[strings.TrimSuffix(following, "s")]
+		_, group := weekdayGroups[strings.TrimSuffix(following, "s")]
+		_, month := months[following]
+		return weekday || group || month || following == "the" || isoDate.MatchString(following)
+	}
+	return false
+}
+
+// referenced finds the reminder that the word refers to, either as "#12" for
+// its ID or "3" for its number on the todo list
+func (ei *EventInit) referenced(word string) (e *Event, ref string, ok bool) {
+	word = strings.Trim(word, punctuation)
+	if strings.HasPrefix(word, "#") {
+		id, err := strconv.ParseInt(word[1:], 10, 64)
+		if err != nil {
+			return nil, "", false
+		}
+		return Queue.find(ei.User, id), fmt.Sprintf("reminder #%d", id), true
+	}
+	n, err := strconv.Atoi(word)
+	if err != nil {
+		return nil, "", false
+	}
+	return numbered(ei.User, n), fmt.Sprintf("number %d", n), true
+}
+
+// ambiguous asks the user to choose between the reminders by number; the
+// example is a request with a %d for the number
+func (ei *EventInit) ambiguous(candidates []*Event, example string) *Event {
+	lines := make([]string, 0)
+	n := 0
+	for i, v := range listed(ei.User) {
+		for _, k := range candidates {
+			if k == v {
+				if n < 1 {
+					n = i + 1
+				}
+				lines = append(lines, fmt.Sprintf("%d. %s (#%d)", i+1, v.task(), v.ID))
+			}
+		}
+	}
+	return ei.sorry(fmt.Sprintf(
+		"Sorry, I'm not sure which reminder you mean:\n%s\nSay `%s` for the first, for example",
+		strings.Join(lines, "\n"),
+		fmt.Sprintf(example, n),
+	))
+}
+
+// notListed is the reply when a reference doesn't match anything on the
+// user's todo list
+func (ei *EventInit) notListed(ref string) *Event {
+	return ei.sorry(fmt.Sprintf("Sorry, there's no %s on your todo list", ref))
+}
+
+// sorry is a reply that leaves the user's reminders as they were
+func (ei *EventInit) sorry(message string) *Event {
+	return &Event{
+		Database: ei.Database,
+		User:     ei.user,
+		Channel:  ei.Channel,
+		Etext:    ei.Etext,
+		Emessage: message,
+		verbatim: true,
+	}
+}
+
+// numbered returns the nth reminder on the user's todo list, counting from one
+func numbered(uhash string, n int) *Event {
+	eventList := listed(uhash)
+	if n < 1 || n > len(eventList) {
+		return nil
+	}
+	return eventList[n-1]
+}
@@ -0,0 +1,239 @@
+package event
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	possessive  = regexp.MustCompile(`['’]s$`)
+	nonWord     = regexp.MustCompile(`[^a-z0-9#]+`)
+	cancelStems = stems(cancellations)
+)
+
+// normalize reduces text to the words that say what it's about, in a form
+// that can be compared with other text: "Done calling my lawyer!" becomes
+// "done", "call" and "lawyer". Punctuation is stripped, stop words and the
+// words of a schedule are dropped, synonyms are replaced with the word they
+// mean and what's left is stemmed.
+func normalize(text string) []string {
+	result := make([]string, 0)
+	for _, v := range sanitize(text) {
+		if v = normalizeWord(v); len(v) > 0 {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+// normalizeWord is normalize for a single word, which is empty if the word
+// doesn't count
+func normalizeWord(word string) string {
+	word = nonWord.ReplaceAllString(possessive.ReplaceAllString(strings.ToLower(word), ""), "")
+	if len(word) < 1 || stopWords[word] || excludeWord(word) {
+		return ""
+	}
+	if means, ok := synonyms[word]; ok {
+		return stem(means)
+	}
+	word = stem(word)
+	if means, ok := synonyms[word]; ok {
+		return stem(means)
+	}
+	return word
+}
+
+// AddSynonym has the word taken to mean the same as another when reminders
+// are compared, as "ring" is taken to mean "call". It isn't safe to call once
+// the bot is handling events.
+func AddSynonym(word, means string) error {
+	word = strings.ToLower(strings.TrimSpace(word))
+	means = strings.ToLower(strings.TrimSpace(means))
+	if len(word) < 1 || len(means) < 1 || strings.ContainsAny(word+means, " \t") {
+		return fmt.Errorf("expected a synonym to be a pair of single words, got '%s' and '%s'", word, means)
+	}
+	synonyms[word] = means
+	return nil
+}
+
+// isCancellation reports whether the word asks for a reminder to be removed,
+// in any of its forms ("cancel", "cancelled", "removing")
+func isCancellation(word string) bool {
+	return cancelStems[normalizeWord(word)]
+}
+
+func stems(words map[string]bool) map[string]bool {
+	result := make(map[string]bool)
+	for k := range words {
+		if v := normalizeWord(k); len(v) > 0 {
+			result[v] = true
+		}
+	}
+	return result
+}
+
+// stem reduces an English word to its stem with the Porter stemming
+// algorithm, so that "call", "calls", "called" and "calling" are all "call"
+func stem(word string) string {
+	if len(word) < 3 {
+		return word
+	}
+	w := []byte(word)
+	w = stemPlurals(w)
+	w = stemEndings(w)
+	if hasVowel(w[:len(w)-1]) && w[len(w)-1] == 'y' {
+		w[len(w)-1] = 'i'
+	}
+	w = replaceSuffix(w, 0, longSuffixes)
+	w = replaceSuffix(w, 0, shortSuffixes)
+	w = stripSuffix(w)
+	return string(stemFinal(w))
+}
+
+// stemPlurals is step 1a: "caresses" is "caress" and "ponies" "poni"
+func stemPlurals(w []byte) []byte {
+	switch {
+	case hasSuffix(w, "sses"), hasSuffix(w, "ies"):
+		return w[:len(w)-2]
+	case hasSuffix(w, "ss"):
+		return w
+	case hasSuffix(w, "s"):
+		return w[:len(w)-1]
+	}
+	return w
+}
+
+// stemEndings is step 1b: "agreed" is "agree", "hopping" "hop" and "filing"
+// "file"
+func stemEndings(w []byte) []byte {
+	if hasSuffix(w, "eed") {
+		if measure(w[:len(w)-3]) > 0 {
+			return w[:len(w)-1]
+		}
+		return w
+	}
+	var stripped []byte
+	for _, suffix := range []string{"ed", "ing"} {
+		if hasSuffix(w, suffix) && hasVowel(w[:len(w)-len(suffix)]) {
+			stripped = w[:len(w)-len(suffix)]
+		}
+	}
+	if stripped == nil {
+		return w
+	}
+	w = stripped
+	switch {
+	case hasSuffix(w, "at"), hasSuffix(w, "bl"), hasSuffix(w, "iz"):
+		return append(w, 'e')
+	case doubleConsonant(w) && !hasSuffix(w, "l") && !hasSuffix(w, "s") && !hasSuffix(w, "z"):
+		return w[:len(w)-1]
+	case measure(w) == 1 && endsCVC(w):
+		return append(w, 'e')
+	}
+	return w
+}
+
+// replaceSuffix is steps 2 and 3, which replace the longest of the suffixes
+// that the word ends with, if what comes before it has more than min
+// consonant-vowel sequences
+func replaceSuffix(w []byte, min int, replacements [][2]string) []byte {
+	for _, r := range replacements {
+		if hasSuffix(w, r[0]) {
+			rest := w[:len(w)-len(r[0])]
+			if measure(rest) > min {
+				return append(rest, r[1]...)
+			}
+			return w
+		}
+	}
+	return w
+}
+
+// stripSuffix is step 4: "adjustment" is "adjust" and "adoption" "adopt"
+func stripSuffix(w []byte) []byte {
+	for _, suffix := range strippedSuffixes {
+		if !hasSuffix(w, suffix) {
+			continue
+		}
+		rest := w[:len(w)-len(suffix)]
+		if suffix == "ion" && !hasSuffix(rest, "s") && !hasSuffix(rest, "t") {
+			return w
+		}
+		if measure(rest) > 1 {
+			return rest
+		}
+		return w
+	}
+	return w
+}
+
+// stemFinal is step 5: "rate" keeps its e, but "probate" is "probat" and
+// "controll" is "control"
+func stemFinal(w []byte) []byte {
+	if hasSuffix(w, "e") {
+		rest := w[:len(w)-1]
+		if m := measure(rest); m > 1 || m == 1 && !endsCVC(rest) {
+			w = rest
+		}
+	}
+	if measure(w) > 1 && doubleConsonant(w) && hasSuffix(w, "l") {
+		w = w[:len(w)-1]
+	}
+	return w
+}
+
+func hasSuffix(w []byte, suffix string) bool {
+	return len(w) > len(suffix) && string(w[len(w)-len(suffix):]) == suffix
+}
+
+func consonant(w []byte, i int) bool {
+	switch w[i] {
+	case 'a', 'e', 'i', 'o', 'u':
+		return false
+	case 'y':
+		return i == 0 || !consonant(w, i-1)
+	}
+	return true
+}
+
+// measure counts the word's consonant-vowel sequences, so that "tree" is 0,
+// "trouble" 1 and "troubles" 2
+func measure(w []byte) int {
+	m := 0
+	vowel := false
+	for i := range w {
+		if !consonant(w, i) {
+			vowel = true
+		} else if vowel {
+			m++
+			vowel = false
+		}
+	}
+	return m
+}
+
+func hasVowel(w []byte) bool {
+	for i := range w {
+		if !consonant(w, i) {
+			return true
+		}
+	}
+	return false
+}
+
+func doubleConsonant(w []byte) bool {
+	n := len(w)
+	return n > 1 && w[n-1] == w[n-2] && consonant(w, n-1)
+}
+
+// endsCVC reports whether the word ends consonant-vowel-consonant, where the
+// last consonant isn't w, x or y, as in "hop" but not "snow"
+func endsCVC(w []byte) bool {
+	n := len(w)
+	if n < 3 || !consonant(w, n-1) || consonant(w, n-2) || !consonant(w, n-3) {
+		return false
+	}
+	last := w[n-1]
+	return last != 'w' && last != 'x' && last != 'y'
+}
@@ -0,0 +1,114 @@
+package event
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStem(t *testing.T) {
+	for _, tc := range []struct {
+		word    string
+		expects string
+	}{
+		{"call", "call"},
+		{"calls", "call"},
+		{"called", "call"},
+		{"calling", "call"},
+		{"caresses", "caress"},
+		{"ponies", "poni"},
+		{"agreed", "agre"},
+		{"hopping", "hop"},
+		{"filing", "file"},
+		{"happy", "happi"},
+		{"relational", "relat"},
+		{"generalization", "gener"},
+		{"hopeful", "hope"},
+		{"goodness", "good"},
+		{"adjustment", "adjust"},
+		{"adoption", "adopt"},
+		{"controlling", "control"},
+		{"rate", "rate"},
+		{"lawyer", "lawyer"},
+		{"cancelled", "cancel"},
+		{"completed", "complet"},
+	} {
+		if got := stem(tc.word); got != tc.expects {
+			t.Errorf("expected '%s' to stem to '%s', got '%s'", tc.word, tc.expects, got)
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	for _, tc := range []struct {
+		text    string
+		expects string
+	}{
+		{"Done calling my lawyer!", "done call lawyer"},
+		{"remind me to call my lawyer every Friday", "call lawyer"},
+		{"Ring mum at 6pm.", "call mom 6pm"},
+		{"phoned Mother", "call mom"},
+		{"Remind me to take my meds every day at 8 am", "take medic"},
+		{"took the pills", "took medic"},
+		{"Pick up the kids' lunches", "pick kid lunch"},
+		{"renew the car's licence in 3 weeks", "renew car licenc"},
+	} {
+		if got := strings.Join(normalize(tc.text), " "); got != tc.expects {
+			t.Errorf("expected '%s' to normalize to '%s', got '%s'", tc.text, tc.expects, got)
+		}
+	}
+}
+
+func TestCancellation(t *testing.T) {
+	for _, tc := range []struct {
+		word    string
+		expects bool
+	}{
+		{"done", true},
+		{"Cancelled:", true},
+		{"canceled", true},
+		{"finished", true},
+		{"removing", true},
+		{"Completed.", true},
+		{"remind", false},
+		{"snooze", false},
+		{"plumber", false},
+	} {
+		if got := isCancellation(tc.word); got != tc.expects {
+			t.Errorf("expected cancellation for '%s' to be %v, got %v", tc.word, tc.expects, got)
+		}
+	}
+}
+
+func TestNormalizedMatches(t *testing.T) {
+	for _, tc := range []struct {
+		reminder string
+		request  string
+	}{
+		{"Remind me to call my lawyer every Friday at 10am", "done calling my lawyer!"},
+		{"Remind me to phone mom every Sunday", "finished ringing mum"},
+		{"Remind me to take my medicine every day at 8 pm", "done, took the pills."},
+		{"Remind me to water the plants every two days", "Cancelled watering plants"},
+	} {
+		ClearQueue()
+		e, err := Create(testMessage(tc.reminder), suite.TestDatabase, suite.TestEnv["slackToken"])
+		if err != nil {
+			t.Fatal(err)
+		}
+		removed, err := Create(testMessage(tc.request), suite.TestDatabase, suite.TestEnv["slackToken"])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if removed != e || Queue.Contains(e) {
+			t.Errorf("expected '%s' to remove '%s', got %s", tc.request, tc.reminder, removed.Message())
+		}
+	}
+	if err := AddSynonym("kitty", "cat"); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(normalize("feed the kitty"), " "); got != "feed cat" {
+		t.Errorf("expected an added synonym to be used, got '%s'", got)
+	}
+	if err := AddSynonym("two words", "cat"); err == nil {
+		t.Errorf("expected an error adding a synonym of more than one word")
+	}
+}
@@ -0,0 +1,247 @@
+package event
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// parseOnce looks for a one-off schedule, either relative to when the message
+// was sent ("in 20 minutes", "in 2 hours") or on a given date ("tomorrow at
+// 9am", "next Tuesday", "on 3 March at 14h00")
+func (e *Event) parseOnce() bool {
+	words := make([]string, 0)
+	for _, v := range e.words() {
+		words = append(words, strings.Trim(v, punctuation))
+	}
+	if e.parseRelative(words) {
+		return true
+	}
+	return e.parseDate(words)
+}
+
+func (e *Event) parseRelative(words []string) bool {
+	for i := 0; i+2 < len(words); i++ {
+		if words[i] != "in" {
+			continue
+		}
+		number := parseNumber(words[i+1])
+		unit := units[strings.TrimSuffix(words[i+2], "s")]
+		if number < 1 || len(unit) < 1 {
+			continue
+		}
+		at := e.reference()
+		switch unit {
+		case "month":
+			at = at.AddDate(0, number, 0)
+		case "year":
+			at = at.AddDate(number, 0, 0)
+		case "week":
+			at = at.AddDate(0, 0, 7*number)
+		case "day":
+			at = at.AddDate(0, 0, number)
+		default:
+			at = at.Add(time.Duration(int64(number)*unitSeconds[unit]) * time.Second)
+		}
+		if unit != "minute" && unit != "hour" {
+			at = e.withTimeOfDay(at)
+		}
+		e.scheduleOnce(at, fmt.Sprintf("in %d %s(s)", number, unit))
+		return true
+	}
+	return false
+}
+
+func (e *Event) parseDate(words []string) bool {
+	ref := e.reference()
+	for i, v := range words {
+		date, ok := e.findDate(words, i, v, ref)
+		if !ok {
+			continue
+		}
+		at := e.withTimeOfDay(date)
+		if at.Unix() <= e.now() {
+			e.passed(at)
+			return true
+		}
+		e.scheduleOnce(at, at.Format("on Monday 2 January at 3:04 pm"))
+		return true
+	}
+	return false
+}
+
+// findDate tries to read a date starting at the word at index i; dates are
+// returned at 9 am (or 8 pm for "tonight") unless a time is given with "at",
+// though "today" and "tonight" are the next hour once that has passed
+func (e *Event) findDate(words []string, i int, v string, ref time.Time) (time.Time, bool) {
+	switch {
+	case v == "today" && len(e.timeOfDay()) > 0:
+		return clock(ref, 9), true
+	case v == "today":
+		if at := clock(ref, 9); at.After(ref) {
+			return at, true
+		}
+		return clock(ref, ref.Hour()+1), true
+	case v == "tonight" && len(e.timeOfDay()) > 0:
+		return clock(ref, 20), true
+	case v == "tonight":
+		if at := clock(ref, 20); at.After(ref) {
+			return at, true
+		}
+		return clock(ref, ref.Hour()+1), true
+	case v == "tomorrow":
+		return clock(ref.AddDate(0, 0, 1), 9), true
+	case (v == "next" || v == "on" || v == "this") && i+1 < len(words):
+		if d, ok := weekdayNames[words[i+1]]; ok {
+			return clock(nextWeekday(ref, d), 9), true
+		}
+	case isoDate.MatchString(v):
+		date, err := time.ParseInLocation("2006-01-02", v, ref.Location())
+		if err == nil {
+			return clock(date, 9), true
+		}
+	}
+	if m, ok := months[v]; ok {
+		return dayOfMonth(words, i, m, ref)
+	}
+	return time.Time{}, false
+}
+
+// dayOfMonth reads the day (and optionally the year) around the month found
+// at index i: "3 March", "3rd of March", "March 3" or "March 3 2023"
+func dayOfMonth(words []string, i int, m time.Month, ref time.Time) (time.Time, bool) {
+	d, next := dayAround(words, i)
+	if d < 1 {
+		return time.Time{}, false
+	}
+	year := ref.Year()
+	explicitYear := false
+	if next < len(words) && len(words[next]) == 4 {
+		if y, err := strconv.Atoi(words[next]); err == nil {
+			year = y
+			explicitYear = true
+		}
+	}
+	date := time.Date(year, m, d, 9, 0, 0, 0, ref.Location())
+	if date.Month() != m {
+		return time.Time{}, false
+	}
+	if !explicitYear && date.Before(clock(ref, 0)) {
+		date = date.AddDate(1, 0, 0)
+	}
+	return date, true
+}
+
+// dayAround reads the day of the month around the month found at index i,
+// and returns it with the index of the word after the month and day
+func dayAround(words []string, i int) (d, next int) {
+	next = i + 1
+	if i > 0 {
+		d = parseOrdinal(words[i-1])
+	}
+	if d < 1 && i > 1 && words[i-1] == "of" {
+		d = parseOrdinal(words[i-2])
+	}
+	if d < 1 && i+1 < len(words) {
+		d = parseOrdinal(words[i+1])
+		next++
+	}
+	return d, next
+}
+
+// withTimeOfDay sets the time of day on date to the time asked for with "at"
+func (e *Event) withTimeOfDay(date time.Time) time.Time {
+	t := e.timeOfDay()
+	if len(t) < 1 {
+		return date
+	}
+	parsed, err := time.Parse("15:04:05", t)
+	if err != nil {
+		return date
+	}
+	return time.Date(date.Year(), date.Month(), date.Day(), parsed.Hour(), parsed.Minute(), 0, 0, date.Location())
+}
+
+func (e *Event) scheduleOnce(at time.Time, message string) {
+	e.Once = true
+	e.Next = at.Unix()
+	e.Emessage = message
+}
+
+// passed answers a one-off reminder asked for at a time that's gone, rather
+// than firing it straight away
+func (e *Event) passed(at time.Time) {
+	e.Once = false
+	e.Schedule = 0
+	e.Emessage = at.Format("Sorry, Monday 2 January at 3:04 pm has already passed")
+	e.verbatim = true
+}
+
+func (e *Event) processedOnce() map[string]string {
+	e.Snoozed = 0
+	e.complete()
+	err := e.save()
+	if err != nil {
+		fmt.Println("error saving event", err.Error())
+	}
+	return map[string]string{
+		"message": fmt.Sprintf("\n'%s'", e.echo()),
+		"heading": fmt.Sprintf("Hi %s, %s", e.UserTag(), "here's your reminder"),
+	}
+}
+
+func (e *Event) complete() {
+	e.Next = 0
+	_, err := e.Exec(completeEvent, []interface{}{e.ID})
+	if err != nil {
+		fmt.Println("error completing event", err.Error())
+	}
+}
+
+// reference is the time the event was sent, in the user's timezone
+func (e *Event) reference() time.Time {
+	return time.Unix(int64(e.Timestamp), 0).In(e.location())
+}
+
+func clock(date time.Time, hours int) time.Time {
+	return time.Date(date.Year(), date.Month(), date.Day(), hours, 0, 0, 0, date.Location())
+}
+
+// nextWeekday finds the first day after ref that falls on d
+func nextWeekday(ref time.Time, d time.Weekday) time.Time {
+	days := (int(d) - int(ref.Weekday()) + 7) % 7
+	if days == 0 {
+		days = 7
+	}
+	return ref.AddDate(0, 0, days)
+}
+
+func parseNumber(word string) int {
+	if word == "a" || word == "an" {
+		return 1
+	}
+	if numbers[word] > 0 {
+		return numbers[word]
+	}
+	if digits[word] > 0 {
+		return digits[word]
+	}
+	number, err := strconv.Atoi(word)
+	if err != nil {
+		return 0
+	}
+	return number
+}
+
+func parseOrdinal(word string) int {
+	parts := ordinalDay.FindStringSubmatch(word)
+	if len(parts) < 2 {
+		return 0
+	}
+	d, _ := strconv.Atoi(parts[1])
+	if d < 1 || d > 31 {
+		return 0
+	}
+	return d
+}
@@ -0,0 +1,75 @@
+package event
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/blainemoser/todobot/user"
+)
+
+// Preview is what the bot makes of a message, worked out without a database
+// or a Slack user so that phrasing can be tried out
+type Preview struct {
+	Command  Command
+	Task     string
+	Schedule string
+	Location *time.Location
+	Next     []time.Time
+}
+
+// PreviewMessage parses the message as though it were sent at the time given
+// by a user in the time zone, and for a new or updated reminder finds the
+// next count times it would fire
+func PreviewMessage(text, tz string, at time.Time, count int) (*Preview, error) {
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return nil, err
+	}
+	_, offset := at.In(loc).Zone()
+	u, err := user.CreateFromRecord(map[string]interface{}{
+		"id":        int64(1),
+		"uhash":     "preview",
+		"tz":        tz,
+		"tz_offset": int64(offset),
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+	p := &Preview{
+		Command:  ParseCommand(text),
+		Location: loc,
+		Next:     make([]time.Time, 0),
+	}
+	if p.Command.Intent != IntentAdd && p.Command.Intent != IntentUpdate {
+		return p, nil
+	}
+	// the event is worked out as of the time given, and never saved or queued
+	e := &Event{
+		User:      u,
+		Timestamp: float64(at.Unix()),
+		Etext:     p.Command.Text,
+		asOf:      at.Unix(),
+	}
+	if len(p.Command.Reference) > 0 {
+		e.Etext = fmt.Sprintf("remind me to %s", p.Command.Text)
+	}
+	e.parseSchedule()
+	e.parseBounds()
+	p.Task = e.task()
+	if e.Schedule < 1 && !e.Once {
+		return p, nil
+	}
+	p.Schedule = e.Emessage
+	for len(p.Next) < count && e.Next > 0 {
+		p.Next = append(p.Next, time.Unix(e.Next, 0).In(loc))
+		if e.Once {
+			break
+		}
+		e.Timestamp = float64(e.Next)
+		e.setNext()
+		if e.lastOccurrence() {
+			break
+		}
+	}
+	return p, nil
+}
@@ -0,0 +1,219 @@
+package event
+
+import (
+	"container/heap"
+	"sort"
+	"sync"
+	"time"
+)
+
+// EventQueue holds the scheduled events in a min-heap ordered by their next
+// occurrence, alongside an index of each user's events.
+//
+// The exported methods are safe for concurrent use. The queue's lock also
+// guards the queued events themselves and the Users map, so the package's
+// entry points (Create, ProcessQueue and so on) hold it for the whole of
+// their work and use the unexported methods, which expect it to be held.
+type EventQueue struct {
+	mu     sync.Mutex
+	events eventHeap
+	users  map[string]map[int64]*Event
+	wake   chan bool
+}
+
+type eventHeap []*Event
+
+func NewQueue() *EventQueue {
+	return &EventQueue{
+		events: make(eventHeap, 0),
+		users:  make(map[string]map[int64]*Event),
+		wake:   make(chan bool, 1),
+	}
+}
+
+func (q *EventQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.events)
+}
+
+// Push adds the event to the queue, or moves it into place if it is already
+// queued and its next occurrence has changed
+func (q *EventQueue) Push(e *Event) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.push(e)
+}
+
+func (q *EventQueue) Remove(e *Event) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.remove(e)
+}
+
+func (q *EventQueue) Contains(e *Event) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.contains(e)
+}
+
+// Due takes the events that are due by now off the queue, earliest first
+func (q *EventQueue) Due(now int64) []*Event {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.due(now)
+}
+
+// Until returns how long it is until the earliest event is due, up to max
+func (q *EventQueue) Until(max time.Duration) time.Duration {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.events) < 1 {
+		return max
+	}
+	wait := time.Until(time.Unix(q.events[0].Next-lookahead, 0))
+	if wait < 0 {
+		return 0
+	}
+	if wait > max {
+		return max
+	}
+	return wait
+}
+
+// Wake signals when an event is pushed to the front of the queue, so that
+// anything waiting on Until can check again
+func (q *EventQueue) Wake() <-chan bool {
+	return q.wake
+}
+
+// UserEvents returns the user's queued events, in the order they were created
+func (q *EventQueue) UserEvents(uhash string) []*Event {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.userEvents(uhash)
+}
+
+// Events returns all of the queued events, in the order they were created
+func (q *EventQueue) Events() []*Event {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	result := make([]*Event, len(q.events))
+	copy(result, q.events)
+	return sortByID(result)
+}
+
+func (q *EventQueue) push(e *Event) {
+	if q.contains(e) {
+		heap.Fix(&q.events, e.index)
+	} else {
+		heap.Push(&q.events, e)
+		q.addToIndex(e)
+	}
+	if e.index == 0 {
+		q.signal()
+	}
+}
+
+func (q *EventQueue) remove(e *Event) {
+	if !q.contains(e) {
+		return
+	}
+	heap.Remove(&q.events, e.index)
+	q.removeFromIndex(e)
+}
+
+func (q *EventQueue) contains(e *Event) bool {
+	return e.index >= 0 && e.index < len(q.events) && q.events[e.index] == e
+}
+
+func (q *EventQueue) due(now int64) []*Event {
+	due := make([]*Event, 0)
+	for len(q.events) > 0 && q.events[0].Next <= now {
+		e := heap.Pop(&q.events).(*Event)
+		q.removeFromIndex(e)
+		due = append(due, e)
+	}
+	return due
+}
+
+// find returns the user's queued event with the ID, if there is one
+func (q *EventQueue) find(uhash string, id int64) *Event {
+	return q.users[uhash][id]
+}
+
+func (q *EventQueue) userEvents(uhash string) []*Event {
+	result := make([]*Event, 0)
+	for _, e := range q.users[uhash] {
+		result = append(result, e)
+	}
+	return sortByID(result)
+}
+
+func (q *EventQueue) clear() {
+	for _, e := range q.events {
+		e.index = -1
+	}
+	q.events = make(eventHeap, 0)
+	q.users = make(map[string]map[int64]*Event)
+}
+
+func (q *EventQueue) signal() {
+	select {
+	case q.wake <- true:
+	default:
+	}
+}
+
+func (q *EventQueue) addToIndex(e *Event) {
+	uhash := e.User.Hash()
+	if q.users[uhash] == nil {
+		q.users[uhash] = make(map[int64]*Event)
+	}
+	q.users[uhash][e.ID] = e
+}
+
+func (q *EventQueue) removeFromIndex(e *Event) {
+	uhash := e.User.Hash()
+	delete(q.users[uhash], e.ID)
+	if len(q.users[uhash]) < 1 {
+		delete(q.users, uhash)
+	}
+}
+
+func sortByID(events []*Event) []*Event {
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].ID < events[j].ID
+	})
+	return events
+}
+
+func (h eventHeap) Len() int {
+	return len(h)
+}
+
+func (h eventHeap) Less(i, j int) bool {
+	return h[i].Next < h[j].Next
+}
+
+func (h eventHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *eventHeap) Push(x interface{}) {
+	e := x.(*Event)
+	e.index = len(*h)
+	*h = append(*h, e)
+}
+
+func (h *eventHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	e := old[n-1]
+	old[n-1] = nil
+	e.index = -1
+	*h = old[:n-1]
+	return e
+}
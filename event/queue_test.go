@@ -0,0 +1,178 @@
+package event
+
+import (
+	"container/list"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/blainemoser/todobot/user"
+)
+
+func TestQueueOrder(t *testing.T) {
+	q := NewQueue()
+	u, err := testQueueUser()
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := make([]*Event, 0)
+	for i, next := range []int64{50, 10, 40, 20, 30} {
+		e := &Event{ID: int64(i + 1), User: u, Next: next}
+		events = append(events, e)
+		q.Push(e)
+	}
+	q.Remove(events[2])
+	if q.Contains(events[2]) {
+		t.Errorf("expected event #%d to have been removed from the queue", events[2].ID)
+	}
+	events[0].Next = 5
+	q.Push(events[0])
+	due := q.Due(25)
+	if len(due) != 3 {
+		t.Fatalf("expected three events to be due, got %d", len(due))
+	}
+	for i, expects := range []int64{5, 10, 20} {
+		if due[i].Next != expects {
+			t.Errorf("expected due event %d to be next at %d, got %d", i, expects, due[i].Next)
+		}
+	}
+	remaining := q.UserEvents(u.Hash())
+	if len(remaining) != 1 || remaining[0].ID != 5 {
+		t.Errorf("expected only event #5 to be left for the user, got %d event(s)", len(remaining))
+	}
+}
+
+func TestQueueWake(t *testing.T) {
+	q := NewQueue()
+	u, err := testQueueUser()
+	if err != nil {
+		t.Fatal(err)
+	}
+	q.Push(&Event{ID: 1, User: u, Next: 100})
+	<-q.Wake()
+	q.Push(&Event{ID: 2, User: u, Next: 200})
+	select {
+	case <-q.Wake():
+		t.Errorf("expected no wake signal when pushing a later event")
+	default:
+	}
+	q.Push(&Event{ID: 3, User: u, Next: 50})
+	select {
+	case <-q.Wake():
+	default:
+		t.Errorf("expected a wake signal when pushing the earliest event")
+	}
+}
+
+// TestQueueConcurrency creates, removes and processes events from several
+// goroutines at once; run it with -race to check the queue's locking
+func TestQueueConcurrency(t *testing.T) {
+	ClearQueue()
+	var wg sync.WaitGroup
+	errs := make(chan error, 32)
+	// each task is distinct, as a task like one already listed is asked about
+	// rather than created
+	tasks := []string{"water the fern", "feed the cat", "call mom", "stretch", "pay rent", "read a book", "walk the dog", "floss"}
+	for i, task := range tasks {
+		wg.Add(4)
+		go func(i int, task string) {
+			defer wg.Done()
+			_, err := Create(testMessage(fmt.Sprintf("Remind me to %s every %d minutes", task, i+1)), suite.TestDatabase, suite.TestEnv["slackToken"])
+			errs <- err
+		}(i, task)
+		go func(task string) {
+			defer wg.Done()
+			_, err := Create(testMessage(fmt.Sprintf("done %s", task)), suite.TestDatabase, suite.TestEnv["slackToken"])
+			errs <- err
+		}(task)
+		go func() {
+			defer wg.Done()
+			_, err := Create(testMessage("done 1"), suite.TestDatabase, suite.TestEnv["slackToken"])
+			errs <- err
+		}()
+		go func() {
+			defer wg.Done()
+			result := make(chan []map[string]string, 1)
+			ProcessQueue(result)
+			<-result
+			Queue.Until(time.Minute)
+			Queue.Events()
+			errs <- nil
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Error(err)
+		}
+	}
+	if len(pending) > 0 {
+		t.Errorf("expected every task to be created rather than asked about")
+	}
+	events := Queue.Events()
+	if len(events) != Queue.Len() {
+		t.Errorf("expected %d queued events, got %d", Queue.Len(), len(events))
+	}
+	for _, e := range events {
+		if !Queue.Contains(e) {
+			t.Errorf("expected event #%d to be in the queue's heap", e.ID)
+		}
+		if e.Schedule < 1 {
+			t.Errorf("expected event #%d to have a schedule", e.ID)
+		}
+	}
+}
+
+// BenchmarkQueueDue processes one due event per iteration from a queue of
+// many, which the heap does in logarithmic time
+func BenchmarkQueueDue(b *testing.B) {
+	for _, size := range []int{1000, 10000, 50000} {
+		b.Run(fmt.Sprintf("%d", size), func(b *testing.B) {
+			q := NewQueue()
+			u, err := testQueueUser()
+			if err != nil {
+				b.Fatal(err)
+			}
+			for i := 0; i < size; i++ {
+				q.Push(&Event{ID: int64(i + 1), User: u, Next: int64(i)})
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				for _, e := range q.Due(int64(i)) {
+					e.Next += int64(size)
+					q.Push(e)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkListScan does the same work as BenchmarkQueueDue by scanning a
+// list, as the queue used to, for comparison
+func BenchmarkListScan(b *testing.B) {
+	for _, size := range []int{1000, 10000, 50000} {
+		b.Run(fmt.Sprintf("%d", size), func(b *testing.B) {
+			l := list.New()
+			for i := 0; i < size; i++ {
+				l.PushBack(&Event{ID: int64(i + 1), Next: int64(i)})
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				for el := l.Front(); el != nil; el = el.Next() {
+					if e := el.Value.(*Event); e.Next <= int64(i) {
+						e.Next += int64(size)
+					}
+				}
+			}
+		})
+	}
+}
+
+func testQueueUser() (*user.User, error) {
+	return user.CreateFromRecord(map[string]interface{}{
+		"id":    int64(1),
+		"uhash": suite.TestEnv["testUser"],
+	}, suite.TestDatabase)
+}
@@ -0,0 +1,126 @@
+package event
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	utils "github.com/blainemoser/goutils"
+)
+
+// snooze handles "snooze 30 minutes" and "snooze until 3pm", which put off the
+// reminder that fired most recently, or the one named or numbered ("snooze
+// the laundry for an hour", "snooze 2"), without changing its schedule
+func (ei *EventInit) snooze(c Command) (*Event, error) {
+	words := make([]string, 0)
+	for _, v := range sanitize(c.Text) {
+		if v = strings.Trim(v, punctuation); len(v) > 0 {
+			words = append(words, v)
+		}
+	}
+	at, description, name := ei.snoozeFor(words)
+	if len(c.Reference) > 0 {
+		e, ref, _ := ei.referenced(c.Reference)
+		if e == nil {
+			return ei.notListed(ref), nil
+		}
+		return e.snoozeUntil(at, description)
+	}
+	targets, err := ei.snoozeTargets(name)
+	if err != nil {
+		return nil, err
+	}
+	if len(targets) < 1 || targets[0] == nil {
+		return ei.sorry("Sorry, I couldn't find a reminder to snooze"), nil
+	}
+	if len(targets) > 1 {
+		return ei.ambiguous(targets, "snooze %d"), nil
+	}
+	return targets[0].snoozeUntil(at, description)
+}
+
+// snoozeFor reads how long to snooze for, returning the words left over,
+// which may name the reminder. It's fifteen minutes unless said otherwise.
+func (ei *EventInit) snoozeFor(words []string) (at int64, description string, name []string) {
+	at = now() + SnoozeFor
+	description = fmt.Sprintf("again in %s", describeSeconds(SnoozeFor))
+	name = make([]string, 0)
+	for i := 0; i < len(words); i++ {
+		v := words[i]
+		if v == "until" || v == "till" || v == "at" {
+			if t, ok := ei.snoozeTime(words[i+1:]); ok {
+				return t.Unix(), fmt.Sprintf("again at %s", t.Format("3:04 pm")), name
+			}
+		}
+		if i+1 < len(words) && parseNumber(v) > 0 {
+			if seconds := unitSeconds[units[strings.TrimSuffix(words[i+1], "s")]]; seconds > 0 {
+				seconds *= int64(parseNumber(v))
+				at = now() + seconds
+				description = fmt.Sprintf("again in %s", describeSeconds(seconds))
+				i++
+				continue
+			}
+		}
+		if v != "for" && v != "in" {
+			name = append(name, v)
+		}
+	}
+	return at, description, name
+}
+
+// snoozeTime reads a time of day, which is taken to be tomorrow's if it has
+// already passed today
+func (ei *EventInit) snoozeTime(words []string) (time.Time, bool) {
+	scratch := &Event{
+		User:      ei.user,
+		Timestamp: float64(now()),
+		Etext:     fmt.Sprintf("at %s", strings.Join(words, " ")),
+	}
+	t := scratch.timeOfDay()
+	if len(t) < 1 {
+		return time.Time{}, false
+	}
+	loc := scratch.location()
+	ref := time.Unix(now(), 0).In(loc)
+	at, err := time.ParseInLocation(tFormat, fmt.Sprintf("%s %s", ref.Format("2006-01-02"), t), loc)
+	if err != nil {
+		return time.Time{}, false
+	}
+	if !at.After(ref) {
+		at = at.AddDate(0, 0, 1)
+	}
+	return at, true
+}
+
+// snoozeTargets finds the user's events that best match the name given, or
+// else the one that fired most recently
+func (ei *EventInit) snoozeTargets(name []string) ([]*Event, error) {
+	if len(name) > 0 {
+		return matching(ei.User, normalize(strings.Join(name, " ")), (*Event).echo), nil
+	}
+	records, err := ei.QueryRaw(lastFiredEvent, []interface{}{ei.User})
+	if err != nil {
+		return nil, err
+	}
+	if len(records) < 1 {
+		return nil, nil
+	}
+	id := utils.Int64Interface(records[0]["id"])
+	if e := Queue.find(ei.User, id); e != nil {
+		return []*Event{e}, nil
+	}
+	return []*Event{reopen(ei.Database, ei.User, id)}, nil
+}
+
+// snoozeUntil moves the event's next reminder to the time given; once it has
+// fired the event carries on from the occurrence it would otherwise have had
+func (e *Event) snoozeUntil(at int64, description string) (*Event, error) {
+	if e.Snoozed < 1 {
+		e.resume = e.Next
+	}
+	e.Snoozed = at
+	e.Next = at
+	e.Emessage = description
+	e.pushToQueue()
+	return e, e.save()
+}
@@ -0,0 +1,25 @@
+package event
+
+import "github.com/blainemoser/MySqlDB/database"
+
+// Stored returns the events in the database, with their user's hash, for the
+// user or for everyone if uhash is empty. Unlike the queue it includes events
+// that have been completed or were never scheduled.
+func Stored(db *database.Database, uhash string) ([]map[string]interface{}, error) {
+	if len(uhash) < 1 {
+		return db.QueryRaw(storedEvents, nil)
+	}
+	return db.QueryRaw(storedUserEvents, []interface{}{uhash})
+}
+
+// PurgeInactive deletes the events that are completed or were never
+// scheduled, such as messages that weren't reminders, once they've been left
+// alone for the number of days, and returns how many were deleted. Active and
+// snoozed events are never purged, so it's safe while the bot is running.
+func PurgeInactive(db *database.Database, days int) (int64, error) {
+	result, err := db.Exec(purgeInactive, []interface{}{days})
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
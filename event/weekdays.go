@@ -0,0 +1,146 @@
+package event
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// parseWeekdays looks for a weekday recurrence rule, such as "every Friday",
+// "every Monday and Thursday", "every weekday" or "on weekends", and records
+// the matching days on the event
+func (e *Event) parseWeekdays() bool {
+	e.weekdays = nil
+	found := make(map[time.Weekday]bool)
+	recurring := false
+	previous := ""
+	for _, word := range e.words() {
+		word = strings.Trim(word, punctuation)
+		if word == "every" {
+			recurring = true
+			previous = word
+			continue
+		}
+		days, plural := lookupWeekdays(word)
+		if !strings.Contains(word, "day") && !introducesDay(previous) {
+			// "sun" and "sat" are as likely to be words as days
+			days, plural = nil, false
+		}
+		if plural {
+			recurring = true
+		}
+		for _, d := range days {
+			found[d] = true
+		}
+		previous = word
+	}
+	if !recurring || len(found) < 1 {
+		return false
+	}
+	e.weekdays = found
+	return true
+}
+
+// lookupWeekdays resolves a single word to the weekdays it names, and reports
+// whether the word was plural ("mondays", "weekends")
+func lookupWeekdays(word string) ([]time.Weekday, bool) {
+	plural := false
+	if _, ok := weekdayNames[word]; !ok && strings.HasSuffix(word, "s") {
+		word = strings.TrimSuffix(word, "s")
+		plural = true
+	}
+	if d, ok := weekdayNames[word]; ok {
+		return []time.Weekday{d}, plural
+	}
+	if group, ok := weekdayGroups[word]; ok {
+		return group, plural
+	}
+	return nil, false
+}
+
+// introducesDay reports whether the word can come before an abbreviated day,
+// as in "every sat", "on mon and thu" or "mon, wed, fri"
+func introducesDay(word string) bool {
+	if word == "every" || word == "on" || word == "and" {
+		return true
+	}
+	days, _ := lookupWeekdays(word)
+	return len(days) > 0
+}
+
+func (e *Event) scheduleWeekdays() {
+	e.Emessage = e.describeWeekdays()
+	e.Schedule = int64(day)
+	e.hasSpecificTime()
+}
+
+func (e *Event) describeWeekdays() string {
+	if e.isWeekdayGroup("weekday") {
+		return "every weekday"
+	}
+	if e.isWeekdayGroup("weekend") {
+		return "on weekends"
+	}
+	names := make([]string, 0)
+	for _, d := range []time.Weekday{
+		time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday, time.Sunday,
+	} {
+		if e.weekdays[d] {
+			names = append(names, d.String())
+		}
+	}
+	if len(names) < 2 {
+		return fmt.Sprintf("every %s", strings.Join(names, ""))
+	}
+	return fmt.Sprintf("every %s and %s", strings.Join(names[:len(names)-1], ", "), names[len(names)-1])
+}
+
+func (e *Event) isWeekdayGroup(group string) bool {
+	if len(e.weekdays) != len(weekdayGroups[group]) {
+		return false
+	}
+	for _, d := range weekdayGroups[group] {
+		if !e.weekdays[d] {
+			return false
+		}
+	}
+	return true
+}
+
+// alignWeekday moves ts forward, a day at a time, until it lands on one of
+// the event's weekdays in the user's timezone
+func (e *Event) alignWeekday(ts int64) int64 {
+	if len(e.weekdays) < 1 {
+		return ts
+	}
+	t := time.Unix(ts, 0).In(e.location())
+	for i := 0; i < 7; i++ {
+		if e.weekdays[t.Weekday()] {
+			break
+		}
+		t = t.AddDate(0, 0, 1)
+	}
+	return t.Unix()
+}
+
+// location is the user's timezone, falling back to their offset from UTC when
+// the zone isn't known
+func (e *Event) location() *time.Location {
+	if len(e.User.TZ()) > 0 {
+		if loc, err := time.LoadLocation(e.User.TZ()); err == nil {
+			return loc
+		}
+	}
+	return time.FixedZone(e.User.TZ(), int(e.User.TZOffset()))
+}
+
+// splitMeridiem separates times written as "4pm" or "8:30am" into the time
+// and the meridiem, so that they parse the same as "4 pm"
+func splitMeridiem(word string) []string {
+	word = strings.Trim(word, punctuation)
+	parts := meridiemTime.FindStringSubmatch(word)
+	if len(parts) != 3 {
+		return []string{word}
+	}
+	return []string{parts[1], parts[2]}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"errors"
+	"flag"
 	"fmt"
 	"log"
 	"os"
@@ -9,32 +10,61 @@ import (
 	"strconv"
 	"strings"
 	"time"
+	_ "time/tzdata"
 
-	jsonextract "github.com/blainemoser/JsonExtract"
 	logging "github.com/blainemoser/Logging"
 	"github.com/blainemoser/MySqlDB/database"
 	utils "github.com/blainemoser/goutils"
-	slackresponse "github.com/blainemoser/slackResponse"
 	"github.com/blainemoser/todobot/api"
+	"github.com/blainemoser/todobot/delivery"
 	"github.com/blainemoser/todobot/event"
+	"github.com/blainemoser/todobot/slackapi"
 )
 
 var (
-	dbExpects = []string{
-		"host",
-		"port",
-		"database",
-		"username",
-		"password",
-		"driver",
-	}
 	db     *database.Database
 	logger *logging.Log
 	a      *api.Api
 	env    map[string]string
 )
 
+const maxSleep = time.Minute
+
+const defaultPort = "8081"
+
+// migrationsDir holds the migrations and the database configs by default
+const migrationsDir = "./migrations"
+
 func main() {
+	args := os.Args[1:]
+	if len(args) < 1 || isPort(args[0]) {
+		// "todobot" and "todobot 8081" start the bot, as they always have
+		args = append([]string{"serve"}, args...)
+	}
+	err := runCommand(args[0], args[1:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// serve starts the bot: todobot serve [port], or --port
+func serve(args []string) error {
+	flags := configFlags("serve")
+	rest, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(rest) > 0 {
+		err = flags.Set("port", rest[0])
+		if err != nil {
+			return err
+		}
+	}
+	err = configure(flags, serveGroups...)
+	if err != nil {
+		return err
+	}
 	hold := make(chan bool, 1)
 	bootstrap()
 	c := make(chan os.Signal, 1)
@@ -45,18 +75,30 @@ func main() {
 		errc <- a.Run()
 	}(errc)
 	close(errc)
-	err := <-errc
+	err = <-errc
 	if err != nil {
 		log.Fatal(err)
 	}
 	go processQueue(a)
 	<-hold
+	return nil
 }
 
 func processQueue(a *api.Api) {
-	tick := time.NewTicker(time.Second * 5)
 	var err error
-	for range tick.C {
+	for {
+		// sleep until the earliest event is due, or until an earlier one is added
+		timer := time.NewTimer(event.Queue.Until(maxSleep))
+		select {
+		case <-timer.C:
+		case <-event.Queue.Wake():
+			timer.Stop()
+			continue
+		}
+		err = delivery.Retry(db, post)
+		if err != nil {
+			a.ErrLog(err, false)
+		}
 		c := make(chan []map[string]string, 1)
 		event.ProcessQueue(c)
 		result := <-c
@@ -70,14 +112,22 @@ func processQueue(a *api.Api) {
 	}
 }
 
+// queueResult records a delivery for each reminder that's due and makes the
+// first attempt at posting it; those that fail are retried later
 func queueResult(result []map[string]string) error {
-	var err error
 	errs := make([]string, 0)
 	for _, v := range result {
 		if v["heading"] == "" || v["message"] == "" {
 			continue
 		}
-		err = slackresponse.SlackPost(v["heading"], v["message"], "INFO", a.SlackURL, logger)
+		eventID, _ := strconv.ParseInt(v["event"], 10, 64)
+		scheduled, _ := strconv.ParseInt(v["scheduled"], 10, 64)
+		d, err := delivery.Record(db, eventID, scheduled, v["channel"], v["heading"], v["message"])
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		err = d.Attempt(post)
 		if err != nil {
 			errs = append(errs, err.Error())
 		}
@@ -88,18 +138,17 @@ func queueResult(result []map[string]string) error {
 	return nil
 }
 
+// post sends a reminder to the channel it was created in, or the webhook's
+// channel if that fails, with buttons to act on it
+func post(d *delivery.Delivery) error {
+	blocks := slackapi.ReminderBlocks(d.EventID, fmt.Sprintf("%s%s", d.Heading, d.Message))
+	return slackapi.Slack(db, env["slackToken"]).Send(d.Channel, d.Heading, d.Message, blocks, a.SlackURL, logger)
+}
+
 func bootstrap() {
 	var err error
 	logger = getLogger()
-	db, err = getDatabase()
-	if err != nil {
-		log.Fatal(err)
-	}
-	err = parseEnv()
-	if err != nil {
-		log.Fatal(err)
-	}
-	a = api.Boot(getPort(), env["slackURL"], env["slackToken"], db, logger)
+	a = api.Boot(getPort(env["port"]), env["slackURL"], env["slackToken"], env["signingSecret"], db, logger)
 	err = event.BootQueue(db)
 	if err != nil {
 		log.Fatal(err)
@@ -110,94 +159,64 @@ func bootstrap() {
 	}
 }
 
-func parseEnv() error {
-	env = make(map[string]string)
-	envConfigs, err := utils.FileConfigs("env.json")
+// configure loads the configs, which the bot and the admin commands share,
+// checks the groups of them the command uses and connects to the database
+func configure(flags *flag.FlagSet, groups ...string) error {
+	values, _, err := loadConfig(flags)
 	if err != nil {
 		return err
 	}
-	err = slackURL(envConfigs)
+	err = validateConfig(values, groups...)
 	if err != nil {
 		return err
 	}
-	return slackToken(envConfigs)
+	env = values
+	for _, g := range groups {
+		if g != botGroup {
+			continue
+		}
+		if err = configureBot(); err != nil {
+			return err
+		}
+	}
+	db, err = getDatabase()
+	return err
 }
 
-func slackURL(envConfigs jsonextract.JSONExtract) error {
-	slackURLInterface, err := envConfigs.Extract("slackURL")
+// configureBot applies the settings that change how the bot handles events
+func configureBot() error {
+	err := event.SetCatchUp(env["catchUp"])
 	if err != nil {
 		return err
 	}
-	slackURL := utils.StringInterface(slackURLInterface)
-	if len(slackURL) < 1 {
-		return fmt.Errorf("no slack url found")
-	}
-	env["slackURL"] = slackURL
-	return nil
-}
-
-func slackToken(envConfigs jsonextract.JSONExtract) error {
-	slackTokenInterface, err := envConfigs.Extract("slackToken")
+	words, err := configSynonyms(env["synonyms"])
 	if err != nil {
 		return err
 	}
-	slackToken := utils.StringInterface(slackTokenInterface)
-	if len(slackToken) < 1 {
-		return fmt.Errorf("no slack token found")
+	for word, means := range words {
+		err = event.AddSynonym(word, means)
+		if err != nil {
+			return err
+		}
 	}
-	env["slackToken"] = slackToken
 	return nil
 }
 
 func getDatabase() (*database.Database, error) {
-	configs, err := utils.FileConfigs("./migrations/configs.json")
-	if err != nil {
-		return nil, err
-	}
-	dbConfigs, err := extractDBConfigs(configs)
-	if err != nil {
-		return nil, err
-	}
-	db, err := database.Make(dbConfigs)
+	db, err := database.Make(&database.Configs{
+		Host:     env["dbHost"],
+		Username: env["dbUsername"],
+		Password: env["dbPassword"],
+		Driver:   env["dbDriver"],
+		Database: env["dbDatabase"],
+		Port:     env["dbPort"],
+	})
 	if err != nil {
 		return nil, err
 	}
 	return &db, nil
 }
 
-func extractDBConfigs(dbConfigs jsonextract.JSONExtract) (*database.Configs, error) {
-	result := make(map[string]string)
-	errs := make([]string, 0)
-	for _, v := range dbExpects {
-		config, err := dbConfigs.Extract(v)
-		if err != nil {
-			errs = append(errs, err.Error())
-			continue
-		}
-		set, ok := config.(string)
-		if !ok {
-			errs = append(errs, fmt.Sprintf("%s is missing", v))
-			continue
-		}
-		result[v] = set
-	}
-	if len(errs) > 0 {
-		return nil, fmt.Errorf("missing configs: %s", strings.Join(errs, ", "))
-	}
-	return makeDBConfigs(result)
-}
-
-func makeDBConfigs(source map[string]string) (*database.Configs, error) {
-	return &database.Configs{
-		Host:     source["host"],
-		Username: source["username"],
-		Password: source["password"],
-		Driver:   source["driver"],
-		Database: source["database"],
-		Port:     source["port"],
-	}, nil
-}
-
 func done(signal chan os.Signal) {
 	// wait on done signal, kill the process if received
 	result := <-signal
@@ -217,16 +236,10 @@ func getLogger() *logging.Log {
 	return l
 }
 
-func getPort() int {
-	args := os.Args
-	var port string
-	if len(args) > 1 {
-		port = args[1]
-	}
+func getPort(port string) int {
 	if len(port) < 1 {
-		port = "8081"
+		port = defaultPort
 	}
-	var err error
 	result, err := strconv.ParseInt(port, 10, 24)
 	if err != nil {
 		log.Println(err)
@@ -234,3 +247,8 @@ func getPort() int {
 	}
 	return int(result)
 }
+
+func isPort(arg string) bool {
+	_, err := strconv.ParseUint(arg, 10, 16)
+	return err == nil
+}
@@ -0,0 +1,20 @@
+-- add your UP SQL here
+[STATEMENT] CREATE TABLE deliveries (
+    id INT(10) UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    event_id INT(6) UNSIGNED NULL,
+    CONSTRAINT deliveries_event_id_foreign FOREIGN KEY (event_id) REFERENCES events(id) ON DELETE set null,
+    scheduled_at BIGINT NULL,
+    status VARCHAR(16) NOT NULL,
+    error TEXT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at BIGINT NULL,
+    heading TEXT,
+    message TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+);
+
+-- [DIRECTION] -- do not alter this line!
+-- add your DOWN SQL here
+
+[STATEMENT] drop table deliveries;
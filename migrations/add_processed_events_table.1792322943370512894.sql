@@ -0,0 +1,11 @@
+-- add your UP SQL here
+[STATEMENT] CREATE TABLE processed_events (
+    event_id VARCHAR(64) PRIMARY KEY,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX processed_events_created_at_index (created_at)
+);
+
+-- [DIRECTION] -- do not alter this line!
+-- add your DOWN SQL here
+
+[STATEMENT] drop table processed_events;
@@ -0,0 +1,8 @@
+-- add your UP SQL here
+
+[STATEMENT] ALTER TABLE deliveries ADD channel VARCHAR(255) NULL AFTER scheduled_at;
+
+-- [DIRECTION] -- do not alter this line!
+-- add your DOWN SQL here
+
+[STATEMENT] ALTER TABLE deliveries DROP COLUMN channel;
@@ -0,0 +1,8 @@
+-- add your UP SQL here
+
+[STATEMENT] ALTER TABLE events ADD cron VARCHAR(255) NULL AFTER schedule;
+
+-- [DIRECTION] -- do not alter this line!
+-- add your DOWN SQL here
+
+[STATEMENT] ALTER TABLE events DROP COLUMN cron;
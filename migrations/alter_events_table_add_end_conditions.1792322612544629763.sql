@@ -0,0 +1,10 @@
+-- add your UP SQL here
+
+[STATEMENT] ALTER TABLE events ADD ends_at BIGINT NULL AFTER once;
+[STATEMENT] ALTER TABLE events ADD remaining INT NULL AFTER ends_at;
+
+-- [DIRECTION] -- do not alter this line!
+-- add your DOWN SQL here
+
+[STATEMENT] ALTER TABLE events DROP COLUMN ends_at;
+[STATEMENT] ALTER TABLE events DROP COLUMN remaining;
@@ -0,0 +1,10 @@
+-- add your UP SQL here
+
+[STATEMENT] ALTER TABLE events ADD next_at BIGINT NULL AFTER remaining;
+[STATEMENT] ALTER TABLE events ADD last_fired_at BIGINT NULL AFTER next_at;
+
+-- [DIRECTION] -- do not alter this line!
+-- add your DOWN SQL here
+
+[STATEMENT] ALTER TABLE events DROP COLUMN next_at;
+[STATEMENT] ALTER TABLE events DROP COLUMN last_fired_at;
@@ -0,0 +1,10 @@
+-- add your UP SQL here
+
+[STATEMENT] ALTER TABLE events ADD once TINYINT(1) NOT NULL DEFAULT 0 AFTER schedule;
+[STATEMENT] ALTER TABLE events ADD completed_at TIMESTAMP NULL AFTER once;
+
+-- [DIRECTION] -- do not alter this line!
+-- add your DOWN SQL here
+
+[STATEMENT] ALTER TABLE events DROP COLUMN once;
+[STATEMENT] ALTER TABLE events DROP COLUMN completed_at;
@@ -0,0 +1,8 @@
+-- add your UP SQL here
+
+[STATEMENT] ALTER TABLE events ADD snoozed_until BIGINT NULL AFTER last_fired_at;
+
+-- [DIRECTION] -- do not alter this line!
+-- add your DOWN SQL here
+
+[STATEMENT] ALTER TABLE events DROP COLUMN snoozed_until;
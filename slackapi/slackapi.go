@@ -1,22 +1,32 @@
 package slackapi
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"time"
 
+	jsonextract "github.com/blainemoser/JsonExtract"
+	logging "github.com/blainemoser/Logging"
 	"github.com/blainemoser/MySqlDB/database"
+	slackresponse "github.com/blainemoser/slackResponse"
 )
 
 const userUrl = `https://slack.com/api/users.info?user=%s&pretty=1`
 
+var postMessageUrl = `https://slack.com/api/chat.postMessage`
+
 var (
 	userExpects []string = []string{
 		"user/tz", "user/tz_offset", "user/tz_label",
 	}
 )
 
+// Block is a Block Kit layout block
+type Block map[string]interface{}
+
 type SlackCall struct {
 	*database.Database
 	http.Client
@@ -47,6 +57,25 @@ func (sc *SlackCall) Get(url string) (string, error) {
 	return sc.getBody(response)
 }
 
+func (sc *SlackCall) Post(url string, payload interface{}) (string, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+	request, err := http.NewRequest("POST", url, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	request.Header.Add("Authorization", fmt.Sprintf("Bearer %s", sc.slackToken))
+	request.Header.Add("Content-Type", "application/json; charset=utf-8")
+	response, err := sc.Do(request)
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+	return sc.getBody(response)
+}
+
 func (sc *SlackCall) getBody(response *http.Response) (string, error) {
 	body, err := ioutil.ReadAll(response.Body)
 	if err != nil {
@@ -59,3 +88,133 @@ func (sc *SlackCall) getBody(response *http.Response) (string, error) {
 func (sc *SlackCall) GetUserDetails(uhash string) (string, error) {
 	return sc.Get(fmt.Sprintf(userUrl, uhash))
 }
+
+// PostMessage posts to a channel as the bot. Given a user's ID rather than a
+// channel's, Slack posts to the bot's direct messages with that user. The
+// blocks are optional, the text is shown in notifications either way.
+func (sc *SlackCall) PostMessage(channel, heading, message string, blocks []Block) error {
+	payload := map[string]interface{}{
+		"channel": channel,
+		"text":    fmt.Sprintf("%s%s", heading, message),
+	}
+	if len(blocks) > 0 {
+		payload["blocks"] = blocks
+	}
+	result, err := sc.Post(postMessageUrl, payload)
+	if err != nil {
+		return err
+	}
+	return slackError(result)
+}
+
+// Send posts to the channel, falling back to the incoming webhook if that
+// can't be done
+func (sc *SlackCall) Send(channel, heading, message string, blocks []Block, webhook string, logger *logging.Log) error {
+	if len(channel) > 0 {
+		err := sc.PostMessage(channel, heading, message, blocks)
+		if err == nil {
+			return nil
+		}
+		fmt.Println("error posting message to channel", channel, err.Error())
+	}
+	return slackresponse.SlackPost(heading, message, "INFO", webhook, logger)
+}
+
+// Respond replies to a slash command or an interaction through its
+// response_url, so that only the user who sent it can see the reply
+func (sc *SlackCall) Respond(responseURL, text string) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"response_type":    "ephemeral",
+		"replace_original": true,
+		"text":             text,
+	})
+	if err != nil {
+		return err
+	}
+	response, err := sc.Client.Post(responseURL, "application/json; charset=utf-8", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		result, _ := sc.getBody(response)
+		return fmt.Errorf("response to slack command failed with status %d: %s", response.StatusCode, result)
+	}
+	return nil
+}
+
+// Update replaces the message that an interaction came from
+func (sc *SlackCall) Update(responseURL, text string) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"replace_original": true,
+		"text":             text,
+	})
+	if err != nil {
+		return err
+	}
+	response, err := sc.Client.Post(responseURL, "application/json; charset=utf-8", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		result, _ := sc.getBody(response)
+		return fmt.Errorf("update of slack message failed with status %d: %s", response.StatusCode, result)
+	}
+	return nil
+}
+
+// ReminderBlocks lays a reminder out with buttons to complete, snooze or skip
+// it, each of which carries the event's ID
+func ReminderBlocks(eventID int64, text string) []Block {
+	if eventID < 1 {
+		return nil
+	}
+	value := fmt.Sprintf("%d", eventID)
+	return []Block{
+		{
+			"type": "section",
+			"text": Block{"type": "mrkdwn", "text": text},
+		},
+		{
+			"type":     "actions",
+			"block_id": "reminder",
+			"elements": []Block{
+				reminderButton("Done", "done", value, "primary"),
+				reminderButton("Snooze 15 minutes", "snooze", value, ""),
+				reminderButton("Skip next", "skip", value, ""),
+			},
+		},
+	}
+}
+
+func reminderButton(text, actionID, value, style string) Block {
+	button := Block{
+		"type":      "button",
+		"text":      Block{"type": "plain_text", "text": text},
+		"action_id": actionID,
+		"value":     value,
+	}
+	if len(style) > 0 {
+		button["style"] = style
+	}
+	return button
+}
+
+func slackError(result string) error {
+	extract := jsonextract.JSONExtract{
+		RawJSON: result,
+	}
+	ok, err := extract.Extract("ok")
+	if err != nil {
+		return fmt.Errorf("unexpected response from Slack API: %s", err.Error())
+	}
+	if ok == true {
+		return nil
+	}
+	reason, err := extract.Extract("error")
+	if err != nil {
+		return fmt.Errorf("request to Slack API failed")
+	}
+	return fmt.Errorf("request to Slack API failed: %v", reason)
+}